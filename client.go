@@ -1,11 +1,15 @@
 package reve
 
 import (
+	"crypto/tls"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/shamspias/reve-go/image"
 	"github.com/shamspias/reve-go/internal/transport"
+	"github.com/shamspias/reve-go/output"
 )
 
 // Default configuration values.
@@ -23,7 +27,8 @@ type Client struct {
 	// Images provides image generation operations.
 	Images *image.Service
 
-	config *Config
+	config    *Config
+	transport *transport.Client
 }
 
 // Config holds client configuration.
@@ -38,9 +43,129 @@ type Config struct {
 	Debug        bool
 	Logger       func(format string, args ...any)
 	Transport    http.RoundTripper
+
+	// VersionPinner records and optionally pins resolved model versions.
+	VersionPinner *image.VersionPinner
+
+	// Workers and QueueSize size the pool used by the Async methods.
+	// Zero uses image.DefaultWorkers and image.DefaultQueueSize.
+	Workers   int
+	QueueSize int
+
+	// Sink receives every successful result, named by SinkTemplate.
+	Sink         output.Sink
+	SinkTemplate output.Template
+
+	// Credentials supplies the API key per request, replacing APIKey.
+	Credentials CredentialsProvider
+
+	// BaseURLs is an ordered list of base URLs to fail over between.
+	// If empty, BaseURL is used.
+	BaseURLs []string
+
+	// BaseURLCooldown is how long a failing base URL is skipped.
+	// Default: 30 seconds
+	BaseURLCooldown time.Duration
+
+	// MaxResponseSize limits response bodies to this many bytes. Zero
+	// means no limit.
+	MaxResponseSize int64
+
+	// Proxy is an http, https, socks5 or socks5h proxy URL. It is used
+	// when Transport is nil.
+	Proxy string
+
+	// ProxyPool spreads requests across several proxy URLs, failing over
+	// between them. It is used when Transport is nil and takes
+	// precedence over Proxy.
+	ProxyPool []string
+
+	// ProxyPoolConfig configures the proxy pool. If nil, proxies are
+	// used round-robin with health checks every 30 seconds.
+	ProxyPoolConfig *ProxyPoolConfig
+
+	// ProxyFromEnvironment uses HTTP_PROXY, HTTPS_PROXY, NO_PROXY and ALL_PROXY
+	// when Transport is nil and Proxy is empty.
+	ProxyFromEnvironment bool
+
+	// DialTimeout, KeepAlive and TLSHandshakeTimeout tune the
+	// connections of the transports the client builds; they do not
+	// apply to a custom Transport. Zero values use the defaults.
+	DialTimeout         time.Duration
+	KeepAlive           time.Duration
+	TLSHandshakeTimeout time.Duration
+
+	// TLSConfig is the base TLS configuration for every transport the
+	// client builds, including the proxy transports. A custom Transport
+	// must be an *http.Transport for it to apply.
+	TLSConfig *tls.Config
+
+	// TLSPins are SHA-256 public key pins ("sha256/<base64>"). A server
+	// must present a certificate matching one of them.
+	TLSPins []string
+
+	// errs collects errors from options. New and Validate report them;
+	// NewClient ignores them.
+	errs []error
+
+	// tlsErrs collects errors from the TLS options. NewClient builds a
+	// client that refuses every request when any are set.
+	tlsErrs []error
+}
+
+// dialOptions returns the connection timeouts for the transport.
+func (c *Config) dialOptions() *transport.DialOptions {
+	return &transport.DialOptions{
+		Timeout:             c.DialTimeout,
+		KeepAlive:           c.KeepAlive,
+		TLSHandshakeTimeout: c.TLSHandshakeTimeout,
+	}
+}
+
+// fail records an option error.
+func (c *Config) fail(err error) {
+	c.errs = append(c.errs, err)
+}
+
+// failTLS records a TLS option error, which NewClient does not ignore.
+func (c *Config) failTLS(err error) {
+	c.fail(err)
+	c.tlsErrs = append(c.tlsErrs, err)
 }
 
-// NewClient creates a new Reve API client.
+// New creates a client and reports invalid options and settings that
+// NewClient would ignore, such as a malformed proxy URL, an API key with
+// whitespace in it, a relative base URL or a negative timeout.
+//
+// Example:
+//
+//	client, err := reve.New(apiKey,
+//		reve.WithHTTPProxy("http://proxy.internal:8080"),
+//		reve.WithTimeout(60*time.Second),
+//	)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+func New(apiKey string, opts ...Option) (*Client, error) {
+	config := defaultConfig()
+	config.APIKey = apiKey
+	for _, opt := range opts {
+		opt(config)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	client, err := newClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// NewClient creates a new Reve API client. Invalid options are ignored;
+// use New to have them reported. Invalid TLS settings, such as a
+// malformed pin or an unreadable CA file, are not: the client then fails
+// every request with ErrTLSConfig rather than connect without them.
 //
 // Example:
 //
@@ -63,8 +188,23 @@ type Config struct {
 //		reve.WithSOCKS5Proxy("127.0.0.1:1080", "user", "pass"),
 //	)
 func NewClient(apiKey string, opts ...Option) *Client {
-	config := &Config{
-		APIKey:       apiKey,
+	config := defaultConfig()
+	config.APIKey = apiKey
+
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	// Proxy errors are reported by New; NewClient keeps its historical
+	// behavior of connecting directly. TLS errors make newClient install
+	// a transport that refuses every request.
+	client, _ := newClient(config)
+	return client
+}
+
+// defaultConfig returns a Config with the default values.
+func defaultConfig() *Config {
+	return &Config{
 		BaseURL:      DefaultBaseURL,
 		Timeout:      DefaultTimeout,
 		MaxRetries:   DefaultMaxRetries,
@@ -72,31 +212,103 @@ func NewClient(apiKey string, opts ...Option) *Client {
 		RetryMaxWait: DefaultRetryMaxWait,
 		UserAgent:    DefaultUserAgent,
 	}
+}
 
-	for _, opt := range opts {
-		opt(config)
+// newClient builds a client from a complete configuration. The client
+// is usable even when an error is returned.
+func newClient(config *Config) (*Client, error) {
+	var errs []error
+	if config.Transport == nil {
+		dial := config.dialOptions()
+		switch {
+		case len(config.ProxyPool) > 0:
+			pool, err := transport.NewProxyPool(config.ProxyPool, config.ProxyPoolConfig, dial)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("reve: proxy pool: %w", err))
+			} else {
+				config.Transport = pool
+			}
+		case config.Proxy != "":
+			t, err := transport.CreateProxyTransport(config.Proxy, dial)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("reve: proxy %s: %w", redactProxy(config.Proxy), err))
+			}
+			config.Transport = t
+		case config.ProxyFromEnvironment:
+			config.Transport = transport.CreateEnvProxyTransportWith(dial)
+		case *dial != (transport.DialOptions{}):
+			config.Transport = transport.CreateDefaultTransport(dial)
+		}
+	}
+	tlsErrs := append([]error(nil), config.tlsErrs...)
+	if err := config.applyTLS(); err != nil {
+		errs = append(errs, err)
+		tlsErrs = append(tlsErrs, err)
+	}
+	if len(tlsErrs) > 0 {
+		config.Transport = refuseTransport{err: fmt.Errorf("%w: %w", ErrTLSConfig, errors.Join(tlsErrs...))}
 	}
 
 	t := transport.New(&transport.Config{
-		BaseURL:      config.BaseURL,
-		APIKey:       config.APIKey,
-		UserAgent:    config.UserAgent,
-		Timeout:      config.Timeout,
-		MaxRetries:   config.MaxRetries,
-		RetryMinWait: config.RetryMinWait,
-		RetryMaxWait: config.RetryMaxWait,
-		Debug:        config.Debug,
-		Logger:       config.Logger,
-		Transport:    config.Transport,
+		BaseURL:         config.BaseURL,
+		APIKey:          config.APIKey,
+		UserAgent:       config.UserAgent,
+		Timeout:         config.Timeout,
+		MaxRetries:      config.MaxRetries,
+		RetryMinWait:    config.RetryMinWait,
+		RetryMaxWait:    config.RetryMaxWait,
+		Debug:           config.Debug,
+		Logger:          config.Logger,
+		Transport:       config.Transport,
+		Credentials:     config.Credentials,
+		BaseURLs:        config.BaseURLs,
+		BaseURLCooldown: config.BaseURLCooldown,
+		MaxResponseSize: config.MaxResponseSize,
 	})
 
 	return &Client{
-		Images: image.NewService(t),
-		config: config,
-	}
+		Images: image.NewService(t,
+			image.WithVersionPinner(config.VersionPinner),
+			image.WithWorkerPool(config.Workers, config.QueueSize),
+			image.WithSink(config.Sink, config.SinkTemplate),
+		),
+		config:    config,
+		transport: t,
+	}, errors.Join(errs...)
 }
 
 // Config returns a copy of the client configuration.
 func (c *Client) Config() Config {
 	return *c.config
 }
+
+// BaseURLStats reports the requests, failures and cooldown of each base
+// URL, in the configured order.
+func (c *Client) BaseURLStats() []BaseURLStats {
+	return c.transport.BaseURLStats()
+}
+
+// ProxyStats reports the state of each proxy configured with
+// WithProxyPool, or nil if the client does not use a proxy pool.
+func (c *Client) ProxyStats() []ProxyStats {
+	if len(c.config.ProxyPool) == 0 {
+		return nil
+	}
+	if pool, ok := c.config.Transport.(*transport.ProxyPool); ok {
+		return pool.Stats()
+	}
+	return nil
+}
+
+// Close stops the worker pool used by the Async methods and the health
+// checks of a proxy pool. Queued jobs are cancelled; running jobs finish
+// first. The synchronous methods keep working after Close.
+func (c *Client) Close() error {
+	c.Images.Close()
+	if len(c.config.ProxyPool) > 0 {
+		if pool, ok := c.config.Transport.(*transport.ProxyPool); ok {
+			pool.Close()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// Command reve-gateway serves the OpenAI Images API backed by Reve.
+//
+// Usage:
+//
+//	REVE_API_KEY=your-key reve-gateway -addr :8080 -public-url https://images.example.com
+//
+// Point OpenAI clients at http://localhost:8080/v1 to use it.
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	reve "github.com/shamspias/reve-go"
+	"github.com/shamspias/reve-go/openai"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	publicURL := flag.String("public-url", "", "external base URL for url responses (default: request host)")
+	ttl := flag.Duration("ttl", openai.DefaultFileTTL, "how long url responses stay available")
+	maxImages := flag.Int("max-n", openai.DefaultMaxImages, "maximum images per request")
+	keys := flag.String("keys", os.Getenv("REVE_GATEWAY_KEYS"), "comma separated bearer tokens clients must send (default: none required)")
+	debug := flag.Bool("debug", false, "log Reve API requests")
+	flag.Parse()
+
+	apiKey := os.Getenv("REVE_API_KEY")
+	if apiKey == "" {
+		log.Fatal("REVE_API_KEY is required")
+	}
+
+	client := reve.NewClient(apiKey, reve.WithDebug(*debug))
+
+	opts := []openai.Option{
+		openai.WithPublicURL(*publicURL),
+		openai.WithFileTTL(*ttl),
+		openai.WithMaxImages(*maxImages),
+	}
+	if *keys != "" {
+		opts = append(opts, openai.WithAPIKeys(strings.Split(*keys, ",")...))
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           openai.NewHandler(client.Images, opts...),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("reve-gateway listening on %s", *addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,70 @@
+// Command reve-mcp is a Model Context Protocol server for Reve.
+//
+// It speaks MCP over stdio and exposes create_image, edit_image,
+// remix_images and estimate_cost tools. Configure it in an MCP client as:
+//
+//	{
+//		"command": "reve-mcp",
+//		"args": ["-out", "/path/to/images"],
+//		"env": {"REVE_API_KEY": "your-key"}
+//	}
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	reve "github.com/shamspias/reve-go"
+	"github.com/shamspias/reve-go/mcp"
+	"github.com/shamspias/reve-go/types"
+)
+
+func main() {
+	out := flag.String("out", ".", "directory generated images are written to")
+	format := flag.String("format", "png", "output format (png, jpeg, webp)")
+	debug := flag.Bool("debug", false, "log Reve API requests to stderr")
+	flag.Parse()
+
+	// stdout carries the protocol, so all logging goes to stderr.
+	log.SetOutput(os.Stderr)
+
+	apiKey := os.Getenv("REVE_API_KEY")
+	if apiKey == "" {
+		log.Fatal("REVE_API_KEY is required")
+	}
+
+	var outputFormat types.OutputFormat
+	switch strings.ToLower(*format) {
+	case "png":
+		outputFormat = types.FormatPNG
+	case "jpg", "jpeg":
+		outputFormat = types.FormatJPEG
+	case "webp":
+		outputFormat = types.FormatWebP
+	default:
+		log.Fatalf("unknown format %q", *format)
+	}
+
+	opts := []reve.Option{}
+	if *debug {
+		opts = append(opts, reve.WithLogger(log.Printf))
+	}
+	client := reve.NewClient(apiKey, opts...)
+
+	srv := mcp.NewServer(client.Images,
+		mcp.WithOutputDir(*out),
+		mcp.WithOutputFormat(outputFormat),
+		mcp.WithServerInfo("reve", reve.Version),
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := srv.Serve(ctx, os.Stdin, os.Stdout); err != nil && !errors.Is(err, context.Canceled) {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,478 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	stdimage "image"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	reve "github.com/shamspias/reve-go"
+	"github.com/shamspias/reve-go/contactsheet"
+	"github.com/shamspias/reve-go/image"
+	"github.com/shamspias/reve-go/types"
+)
+
+// genFlags map onto the shared fields of CreateParams, EditParams and RemixParams.
+type genFlags struct {
+	globalFlags
+	aspect     string
+	version    string
+	fast       bool
+	upscale    int
+	removeBG   bool
+	scaling    float64
+	format     string
+	output     string
+	dir        string
+	breadcrumb string
+	provenance bool
+	sidecar    bool
+}
+
+func (f *genFlags) register(fs *flag.FlagSet) {
+	f.globalFlags.register(fs)
+	fs.StringVar(&f.aspect, "aspect", "", "aspect ratio (16:9, 9:16, 3:2, 2:3, 4:3, 3:4, 1:1, auto)")
+	fs.StringVar(&f.version, "version", "", "model version (latest, latest-fast or a dated version)")
+	fs.BoolVar(&f.fast, "fast", false, "use the fast model (same as -version latest-fast)")
+	fs.IntVar(&f.upscale, "upscale", 0, "upscale factor (2, 3 or 4)")
+	fs.BoolVar(&f.removeBG, "remove-bg", false, "remove the background")
+	fs.Float64Var(&f.scaling, "scaling", 0, "test time scaling (1-15)")
+	fs.StringVar(&f.format, "format", "png", "output format (png, jpeg, webp)")
+	fs.StringVar(&f.output, "o", "", "output `file` (default: <command>-<request id>.<ext>)")
+	fs.StringVar(&f.dir, "dir", ".", "output `directory`")
+	fs.StringVar(&f.breadcrumb, "breadcrumb", "", "tracking ID sent with the request")
+	fs.BoolVar(&f.provenance, "provenance", false, "embed the prompt, version and request ID as XMP metadata")
+	fs.BoolVar(&f.sidecar, "sidecar", false, "with -provenance, also write <file>.json")
+}
+
+// saveOptions returns the options for saving a result of endpoint.
+func (f *genFlags) saveOptions(endpoint types.Endpoint, prompt string) []types.SaveOption {
+	if !f.provenance {
+		return nil
+	}
+	opts := []types.SaveOption{types.WithProvenance(types.Provenance{
+		Prompt:     prompt,
+		Endpoint:   endpoint,
+		Breadcrumb: f.breadcrumb,
+	})}
+	if f.sidecar {
+		opts = append(opts, types.WithSidecar())
+	}
+	return opts
+}
+
+// parse parses args and rejects conflicting flags.
+func (f *genFlags) parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if f.fast && f.version != "" {
+		return errors.New("-fast and -version cannot be used together")
+	}
+	return nil
+}
+
+func (f *genFlags) modelVersion() types.ModelVersion {
+	if f.fast {
+		return types.VersionLatestFast
+	}
+	return types.ModelVersion(f.version)
+}
+
+func (f *genFlags) postprocess() []types.Postprocess {
+	var pp []types.Postprocess
+	if f.upscale != 0 {
+		pp = append(pp, types.Upscale(f.upscale))
+	}
+	if f.removeBG {
+		pp = append(pp, types.RemoveBackground())
+	}
+	return pp
+}
+
+func (f *genFlags) outputFormat() (types.OutputFormat, error) {
+	switch strings.ToLower(f.format) {
+	case "png":
+		return types.FormatPNG, nil
+	case "jpg", "jpeg":
+		return types.FormatJPEG, nil
+	case "webp":
+		return types.FormatWebP, nil
+	}
+	return "", fmt.Errorf("unknown output format %q", f.format)
+}
+
+// outputPath returns where to write a result.
+func (f *genFlags) outputPath(command, requestID string, format types.OutputFormat) string {
+	if f.output != "" {
+		return f.output
+	}
+	return filepath.Join(f.dir, resultName(command, requestID, format.Extension()))
+}
+
+func resultName(command, requestID, ext string) string {
+	if requestID == "" {
+		requestID = time.Now().Format("20060102-150405.000")
+	}
+	return command + "-" + requestID + ext
+}
+
+func runCreate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	var f genFlags
+	f.register(fs)
+	if err := f.parse(fs, args); err != nil {
+		return err
+	}
+	prompt := strings.Join(fs.Args(), " ")
+
+	format, err := f.outputFormat()
+	if err != nil {
+		return err
+	}
+	client, err := newClient(&f.globalFlags)
+	if err != nil {
+		return err
+	}
+
+	result, err := client.Images.CreateRaw(ctx, &image.CreateParams{
+		Prompt:          prompt,
+		AspectRatio:     types.AspectRatio(f.aspect),
+		Version:         f.modelVersion(),
+		Postprocess:     f.postprocess(),
+		TestTimeScaling: f.scaling,
+		Breadcrumb:      f.breadcrumb,
+	}, format)
+	if err != nil {
+		return err
+	}
+	return saveRaw(result, f.outputPath("create", result.RequestID, format), f.saveOptions(types.EndpointCreate, prompt)...)
+}
+
+func runEdit(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ContinueOnError)
+	var f genFlags
+	var imagePath string
+	f.register(fs)
+	fs.StringVar(&imagePath, "image", "", "image `file` to edit (required)")
+	if err := f.parse(fs, args); err != nil {
+		return err
+	}
+	if imagePath == "" {
+		return errors.New("-image is required")
+	}
+
+	format, err := f.outputFormat()
+	if err != nil {
+		return err
+	}
+	img, err := types.NewImageFromFile(imagePath)
+	if err != nil {
+		return err
+	}
+	client, err := newClient(&f.globalFlags)
+	if err != nil {
+		return err
+	}
+
+	instruction := strings.Join(fs.Args(), " ")
+	result, err := client.Images.EditRaw(ctx, &image.EditParams{
+		Instruction:     instruction,
+		ReferenceImage:  img.Base64(),
+		AspectRatio:     types.AspectRatio(f.aspect),
+		Version:         f.modelVersion(),
+		Postprocess:     f.postprocess(),
+		TestTimeScaling: f.scaling,
+		Breadcrumb:      f.breadcrumb,
+	}, format)
+	if err != nil {
+		return err
+	}
+	return saveRaw(result, f.outputPath("edit", result.RequestID, format), f.saveOptions(types.EndpointEdit, instruction)...)
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+func runRemix(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("remix", flag.ContinueOnError)
+	var f genFlags
+	var images stringList
+	f.register(fs)
+	fs.Var(&images, "image", "reference image `file`, repeat for <img>0</img>, <img>1</img>, ...")
+	if err := f.parse(fs, args); err != nil {
+		return err
+	}
+
+	format, err := f.outputFormat()
+	if err != nil {
+		return err
+	}
+	refs := make([]string, 0, len(images))
+	for _, path := range images {
+		img, err := types.NewImageFromFile(path)
+		if err != nil {
+			return err
+		}
+		refs = append(refs, img.Base64())
+	}
+	client, err := newClient(&f.globalFlags)
+	if err != nil {
+		return err
+	}
+
+	params := &image.RemixParams{
+		Prompt:          strings.Join(fs.Args(), " "),
+		ReferenceImages: refs,
+		AspectRatio:     types.AspectRatio(f.aspect),
+		Version:         f.modelVersion(),
+		Postprocess:     f.postprocess(),
+		TestTimeScaling: f.scaling,
+		Breadcrumb:      f.breadcrumb,
+	}
+	for _, w := range params.Warnings() {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+
+	result, err := client.Images.RemixRaw(ctx, params, format)
+	if err != nil {
+		return err
+	}
+	return saveRaw(result, f.outputPath("remix", result.RequestID, format), f.saveOptions(types.EndpointRemix, params.Prompt)...)
+}
+
+func runBatch(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	var f genFlags
+	var file string
+	var concurrency int
+	var stopOnError bool
+	var sheet string
+	f.register(fs)
+	fs.StringVar(&file, "file", "", "prompts `file`, one prompt per line (\"-\" for stdin)")
+	fs.IntVar(&concurrency, "concurrency", 5, "maximum concurrent requests")
+	fs.BoolVar(&stopOnError, "stop-on-error", false, "stop after the first failure")
+	fs.StringVar(&sheet, "sheet", "", "also write a contact sheet of all results to `file` (PNG)")
+	if err := f.parse(fs, args); err != nil {
+		return err
+	}
+	if file == "" {
+		return errors.New("-file is required")
+	}
+	if concurrency < 1 {
+		return fmt.Errorf("-concurrency must be at least 1, got %d", concurrency)
+	}
+	if f.output != "" {
+		return errors.New("-o cannot be used with batch; use -dir")
+	}
+
+	format, err := f.outputFormat()
+	if err != nil {
+		return err
+	}
+	if sheet != "" && format == types.FormatWebP {
+		return errors.New("-sheet cannot be used with -format webp")
+	}
+	prompts, err := readPrompts(file)
+	if err != nil {
+		return err
+	}
+	client, err := newClient(&f.globalFlags)
+	if err != nil {
+		return err
+	}
+
+	params := make([]*image.CreateParams, len(prompts))
+	for i, prompt := range prompts {
+		params[i] = &image.CreateParams{
+			Prompt:          prompt,
+			AspectRatio:     types.AspectRatio(f.aspect),
+			Version:         f.modelVersion(),
+			Postprocess:     f.postprocess(),
+			TestTimeScaling: f.scaling,
+			Breadcrumb:      f.breadcrumb,
+		}
+	}
+
+	if err := os.MkdirAll(f.dir, 0o755); err != nil {
+		return err
+	}
+
+	results := createAll(ctx, client, params, format, concurrency, stopOnError)
+
+	// Results are decoded only for the contact sheet.
+	var items []contactsheet.Item
+	failed := 0
+	for i, r := range results {
+		item := contactsheet.Item{Index: i, Prompt: prompts[i], Err: r.err}
+		if r.err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "[%d] failed: %v\n", i, r.err)
+		} else {
+			path := filepath.Join(f.dir, batchName(i, r.result.RequestID, format))
+			if err := saveRaw(r.result, path, f.saveOptions(types.EndpointCreate, prompts[i])...); err != nil {
+				return err
+			}
+			if sheet != "" {
+				item.Version, item.CreditsUsed = r.result.Version, r.result.CreditsUsed
+				if item.Image, _, item.Err = stdimage.Decode(bytes.NewReader(r.result.Data)); item.Err != nil {
+					item.Err = fmt.Errorf("decode: %w", item.Err)
+				}
+			}
+			results[i].result = nil
+		}
+		if sheet != "" {
+			items = append(items, item)
+		}
+	}
+
+	if sheet != "" {
+		if err := contactsheet.WriteFile(sheet, items, nil); err != nil {
+			return err
+		}
+		fmt.Println(sheet)
+	}
+
+	fmt.Printf("%d/%d succeeded\n", len(results)-failed, len(results))
+	if failed > 0 {
+		return fmt.Errorf("%d request(s) failed", failed)
+	}
+	return nil
+}
+
+// batchResult is the outcome of one request in a batch.
+type batchResult struct {
+	result *types.RawResult
+	err    error
+}
+
+// createAll runs a raw create request for each of params, at most
+// concurrency at a time. With stopOnError, requests not yet started when
+// one fails are skipped with context.Canceled.
+func createAll(ctx context.Context, client *reve.Client, params []*image.CreateParams, format types.OutputFormat, concurrency int, stopOnError bool) []batchResult {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]batchResult, len(params))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, p := range params {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i].result, results[i].err = client.Images.CreateRaw(ctx, p, format)
+			if results[i].err != nil && stopOnError {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// batchName names the result of the batch request at index.
+func batchName(index int, requestID string, format types.OutputFormat) string {
+	return fmt.Sprintf("batch-%03d-%s%s", index, requestID, format.Extension())
+}
+
+func readPrompts(path string) ([]string, error) {
+	f := os.Stdin
+	if path != "-" {
+		var err error
+		if f, err = os.Open(path); err != nil {
+			return nil, err
+		}
+		defer f.Close()
+	}
+
+	var prompts []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		prompts = append(prompts, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(prompts) == 0 {
+		return nil, fmt.Errorf("no prompts in %s", path)
+	}
+	return prompts, nil
+}
+
+func runEstimate(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("estimate", flag.ContinueOnError)
+	var f genFlags
+	var count int
+	f.register(fs)
+	fs.IntVar(&count, "n", 1, "number of requests")
+	if err := f.parse(fs, args); err != nil {
+		return err
+	}
+
+	endpoint := "create"
+	if fs.NArg() > 0 {
+		endpoint = fs.Arg(0)
+	}
+	fast := f.modelVersion().IsFast()
+
+	var cost image.Cost
+	switch endpoint {
+	case "create":
+		cost = image.EstimateCreate(f.scaling, f.postprocess())
+	case "edit":
+		cost = image.EstimateEdit(fast, f.scaling, f.postprocess())
+	case "remix":
+		cost = image.EstimateRemix(fast, f.scaling, f.postprocess())
+	default:
+		return fmt.Errorf("unknown endpoint %q (want create, edit or remix)", endpoint)
+	}
+
+	fmt.Printf("%s: %s per request\n", endpoint, cost)
+	if count > 1 {
+		fmt.Printf("total for %d: %s\n", count, image.FormatCredits(cost.TotalCredits*count))
+	}
+	return nil
+}
+
+func saveRaw(result *types.RawResult, path string, opts ...types.SaveOption) error {
+	if result.ContentViolation {
+		fmt.Fprintln(os.Stderr, "warning: content policy violation reported")
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	if err := result.SaveTo(path, opts...); err != nil {
+		return err
+	}
+	printResult(path, result.RequestID, result.Version, result.CreditsUsed, result.CreditsRemaining)
+	return nil
+}
+
+func printResult(path, requestID, version string, used, remaining int) {
+	fmt.Printf("%s  request_id=%s version=%s credits_used=%d credits_remaining=%d\n",
+		path, requestID, version, used, remaining)
+}
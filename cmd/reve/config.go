@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"time"
+
+	reve "github.com/shamspias/reve-go"
+)
+
+// configPath returns the config file to use: the -config flag, then
+// $REVE_CONFIG, then <user config dir>/reve/config.json if it exists.
+func configPath(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if env := os.Getenv(reve.EnvPrefix + "CONFIG"); env != "" {
+		return env
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(dir, "reve", "config.json")
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return ""
+	}
+	return path
+}
+
+// newClient builds a client from the config file and REVE_* environment
+// variables, with the command line flags applied last.
+func newClient(g *globalFlags) (*reve.Client, error) {
+	cfg, err := reve.LoadConfig(configPath(g.config))
+	if err != nil {
+		return nil, err
+	}
+	if g.timeout > 0 {
+		cfg.Timeout = g.timeout
+	}
+	if g.debug {
+		cfg.Debug = true
+	}
+	return reve.NewClientFromConfig(cfg)
+}
+
+// globalFlags are shared by every command that calls the API.
+type globalFlags struct {
+	config  string
+	timeout time.Duration
+	debug   bool
+}
+
+func (g *globalFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.config, "config", "", "config `file` (.json, .yaml or .toml)")
+	fs.DurationVar(&g.timeout, "timeout", 0, "request timeout (default from the config, else 2m0s)")
+	fs.BoolVar(&g.debug, "debug", false, "log HTTP requests")
+}
@@ -0,0 +1,82 @@
+// Command reve generates, edits and remixes images with the Reve API.
+//
+// Usage:
+//
+//	reve create [flags] <prompt>
+//	reve edit [flags] -image photo.png <instruction>
+//	reve remix [flags] -image a.png -image b.png <prompt>
+//	reve batch [flags] -file prompts.txt
+//	reve estimate [flags] create|edit|remix
+//
+// Settings are read with reve.LoadConfig from the config file (-config,
+// $REVE_CONFIG or <user config dir>/reve/config.json) and the REVE_*
+// environment variables, such as REVE_API_KEY and REVE_BASE_URL.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+const usage = `Usage: reve <command> [flags] [arguments]
+
+Commands:
+  create     Generate an image from a text prompt
+  edit       Edit an image with a text instruction
+  remix      Combine reference images with a text prompt
+  batch      Generate one image per line of a prompts file
+  estimate   Estimate the credit cost of a request
+
+Run "reve <command> -h" for command flags.
+`
+
+type command func(ctx context.Context, args []string) error
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run executes a command line without the program name and returns the
+// exit code: 0 on success, 1 if the command failed and 2 for a missing or
+// unknown command.
+func run(args []string) int {
+	if len(args) < 1 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	commands := map[string]command{
+		"create":   runCreate,
+		"edit":     runEdit,
+		"remix":    runRemix,
+		"batch":    runBatch,
+		"estimate": runEstimate,
+	}
+
+	name := args[0]
+	if name == "-h" || name == "--help" || name == "help" {
+		fmt.Print(usage)
+		return 0
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "reve: unknown command %q\n\n%s", name, usage)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := cmd(ctx, args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "reve %s: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shamspias/reve-go/revetest"
+	"github.com/shamspias/reve-go/types"
+)
+
+// setEnv points the command at srv and isolates it from the user's
+// config and environment.
+func setEnv(t *testing.T, srv *revetest.Server) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("REVE_CONFIG", "")
+	t.Setenv("REVE_API_KEY", "test-key")
+	t.Setenv("REVE_BASE_URL", srv.URL)
+	t.Setenv("REVE_MAX_RETRIES", "0")
+}
+
+func TestGenFlags(t *testing.T) {
+	tests := []struct {
+		args        []string
+		format      types.OutputFormat
+		version     types.ModelVersion
+		postprocess []types.Postprocess
+		wantErr     bool
+	}{
+		{args: nil, format: types.FormatPNG},
+		{args: []string{"-format", "JPG"}, format: types.FormatJPEG},
+		{args: []string{"-format", "webp", "-fast"}, format: types.FormatWebP, version: types.VersionLatestFast},
+		{args: []string{"-version", "reve-create@20250915"}, format: types.FormatPNG, version: "reve-create@20250915"},
+		{
+			args:        []string{"-upscale", "2", "-remove-bg"},
+			format:      types.FormatPNG,
+			postprocess: []types.Postprocess{types.Upscale(2), types.RemoveBackground()},
+		},
+		{args: []string{"-format", "gif"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			var f genFlags
+			f.register(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			format, err := f.outputFormat()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("outputFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if format != tt.format {
+				t.Errorf("outputFormat() = %q, want %q", format, tt.format)
+			}
+			if v := f.modelVersion(); v != tt.version {
+				t.Errorf("modelVersion() = %q, want %q", v, tt.version)
+			}
+			if pp := f.postprocess(); !reflect.DeepEqual(pp, tt.postprocess) {
+				t.Errorf("postprocess() = %v, want %v", pp, tt.postprocess)
+			}
+		})
+	}
+}
+
+func TestOutputNames(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"default", (&genFlags{dir: "."}).outputPath("create", "req1", types.FormatPNG), "create-req1.png"},
+		{"dir", (&genFlags{dir: "out"}).outputPath("edit", "req2", types.FormatWebP), filepath.Join("out", "edit-req2.webp")},
+		{"explicit", (&genFlags{dir: "out", output: "cat.jpeg"}).outputPath("remix", "req3", types.FormatJPEG), "cat.jpeg"},
+		{"batch png", batchName(7, "req4", types.FormatPNG), "batch-007-req4.png"},
+		{"batch jpeg", batchName(12, "req5", types.FormatJPEG), "batch-012-req5.jpeg"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		fail    []revetest.Failure
+		code    int
+		outputs string // glob of files expected in the output directory
+	}{
+		{name: "no command", args: nil, code: 2},
+		{name: "unknown command", args: []string{"paint"}, code: 2},
+		{name: "help", args: []string{"help"}, code: 0},
+		{name: "command help", args: []string{"create", "-h"}, code: 0},
+		{name: "create", args: []string{"create", "a lighthouse"}, code: 0, outputs: "create-*.png"},
+		{name: "create webp", args: []string{"create", "-format", "webp", "a lighthouse"}, code: 0, outputs: "create-*.webp"},
+		{name: "create bad format", args: []string{"create", "-format", "gif", "a lighthouse"}, code: 1},
+		{name: "create bad flag", args: []string{"create", "-nope", "a lighthouse"}, code: 1},
+		{name: "create API error", args: []string{"create", "a lighthouse"}, fail: []revetest.Failure{revetest.InsufficientCredits()}, code: 1},
+		{name: "create empty prompt", args: []string{"create"}, code: 1},
+		{name: "edit without image", args: []string{"edit", "add fog"}, code: 1},
+		{name: "batch", args: []string{"batch", "-file", "prompts.txt", "-format", "jpeg"}, code: 0, outputs: "batch-00[01]-*.jpeg"},
+		{name: "batch without file", args: []string{"batch"}, code: 1},
+		{name: "batch zero concurrency", args: []string{"batch", "-file", "prompts.txt", "-concurrency", "0"}, code: 1},
+		{name: "batch partial failure", args: []string{"batch", "-file", "prompts.txt"}, fail: []revetest.Failure{revetest.InsufficientCredits()}, code: 1, outputs: "batch-*.png"},
+		{name: "batch webp", args: []string{"batch", "-file", "prompts.txt", "-format", "webp"}, code: 0, outputs: "batch-00[01]-*.webp"},
+		{name: "batch sheet", args: []string{"batch", "-file", "prompts.txt", "-sheet", "sheet.png"}, code: 0, outputs: "sheet.png"},
+		{name: "batch sheet webp", args: []string{"batch", "-file", "prompts.txt", "-format", "webp", "-sheet", "sheet.png"}, code: 1},
+		{name: "batch output file", args: []string{"batch", "-file", "prompts.txt", "-o", "out.png"}, code: 1},
+		{name: "create fast and version", args: []string{"create", "-fast", "-version", "reve-create@20250915", "a lighthouse"}, code: 1},
+		{name: "estimate", args: []string{"estimate", "remix"}, code: 0},
+		{name: "estimate unknown endpoint", args: []string{"estimate", "paint"}, code: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := revetest.NewServer(t)
+			setEnv(t, srv)
+			srv.FailNext(tt.fail...)
+
+			dir := t.TempDir()
+			prompts := filepath.Join(dir, "prompts.txt")
+			if err := os.WriteFile(prompts, []byte("a red apple\n# skipped\na green pear\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			args := make([]string, len(tt.args))
+			for i, a := range tt.args {
+				switch a {
+				case "prompts.txt":
+					a = prompts
+				case "sheet.png":
+					a = filepath.Join(dir, a)
+				}
+				args[i] = a
+			}
+			if len(args) > 1 && (args[0] == "create" || args[0] == "batch") {
+				args = append([]string{args[0], "-dir", dir}, args[1:]...)
+			}
+
+			if code := run(args); code != tt.code {
+				t.Errorf("run(%q) = %d, want %d", tt.args, code, tt.code)
+			}
+			if tt.code != 0 && tt.fail == nil && len(srv.Requests()) > 0 {
+				t.Errorf("run(%q) sent %d requests for a usage error", tt.args, len(srv.Requests()))
+			}
+			if tt.outputs != "" {
+				matches, _ := filepath.Glob(filepath.Join(dir, tt.outputs))
+				if len(matches) == 0 {
+					entries, _ := os.ReadDir(dir)
+					t.Errorf("no output matching %s in %v", tt.outputs, entries)
+				}
+			}
+		})
+	}
+}
+
+func TestRunWithoutAPIKey(t *testing.T) {
+	srv := revetest.NewServer(t)
+	setEnv(t, srv)
+	t.Setenv("REVE_API_KEY", "")
+	if code := run([]string{"create", "a lighthouse"}); code != 1 {
+		t.Errorf("run() without API key = %d, want 1", code)
+	}
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("server got %d requests without an API key", n)
+	}
+
+	// The config file is read with reve.LoadConfig.
+	config := filepath.Join(t.TempDir(), "reve.yaml")
+	if err := os.WriteFile(config, []byte("api_key: from-file\nbase_url: "+srv.URL+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if code := run([]string{"create", "-config", config, "-dir", dir, "a lighthouse"}); code != 0 {
+		t.Errorf("run() with config file = %d, want 0", code)
+	}
+	if code := run([]string{"create", "-config", filepath.Join(dir, "missing.json"), "a lighthouse"}); code != 1 {
+		t.Errorf("run() with missing config file = %d, want 1", code)
+	}
+}
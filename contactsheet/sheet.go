@@ -0,0 +1,313 @@
+// Package contactsheet renders batch results as a labelled grid image,
+// so a set of candidates can be reviewed side by side.
+//
+// Only the standard library image packages are used. Captions are drawn
+// with a built-in 5x7 bitmap font; characters outside printable ASCII
+// are shown as '?'.
+//
+// # Usage
+//
+//	results := client.Images.BatchCreate(ctx, params, nil)
+//	items := contactsheet.FromBatch(results, prompts)
+//	err := contactsheet.WriteFile("sheet.png", items, &contactsheet.Options{
+//		Columns: 4,
+//	})
+package contactsheet
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // decode JPEG results
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	reveimage "github.com/shamspias/reve-go/image"
+)
+
+// Caption selects the fields shown under each cell.
+type Caption uint8
+
+// Caption fields.
+const (
+	CaptionIndex Caption = 1 << iota
+	CaptionPrompt
+	CaptionVersion
+	CaptionCredits
+
+	CaptionAll = CaptionIndex | CaptionPrompt | CaptionVersion | CaptionCredits
+)
+
+// Default layout values.
+const (
+	DefaultCellWidth  = 256
+	DefaultCellHeight = 256
+	DefaultPadding    = 8
+	DefaultFontScale  = 2
+)
+
+// Options configures the grid layout. The zero value is usable.
+type Options struct {
+	// Columns is the number of columns.
+	// Default: the square root of the item count, rounded up.
+	Columns int
+
+	// CellWidth and CellHeight bound each image. Images are scaled to
+	// fit, keeping their aspect ratio.
+	// Default: 256x256
+	CellWidth  int
+	CellHeight int
+
+	// Padding is the space around and between cells. Use a negative
+	// value for no padding.
+	// Default: 8
+	Padding int
+
+	// Captions selects the caption fields.
+	// Default: CaptionAll
+	Captions Caption
+
+	// HideCaptions leaves out the caption area entirely.
+	HideCaptions bool
+
+	// FontScale multiplies the 5x7 caption font.
+	// Default: 2
+	FontScale int
+
+	// Background, Text and Placeholder are the sheet, caption and
+	// failed-cell colors.
+	// Default: white, near-black and light grey
+	Background  color.Color
+	Text        color.Color
+	Placeholder color.Color
+}
+
+// Item is one cell of the sheet.
+type Item struct {
+	// Image is the decoded image. A nil Image renders a placeholder.
+	Image image.Image
+
+	// Err explains a failed item and is shown on its placeholder.
+	Err error
+
+	Index       int
+	Prompt      string
+	Version     string
+	CreditsUsed int
+}
+
+// FromBatch converts batch results to items, decoding each image.
+// prompts, if given, supplies the prompt for each result index. Results
+// that failed or cannot be decoded become placeholders.
+func FromBatch(results []reveimage.BatchResult, prompts []string) []Item {
+	items := make([]Item, len(results))
+	for i, r := range results {
+		item := Item{Index: r.Index, Err: r.Error}
+		if r.Index >= 0 && r.Index < len(prompts) {
+			item.Prompt = prompts[r.Index]
+		}
+		if r.Error == nil && r.Result != nil {
+			item.Version = r.Result.Version
+			item.CreditsUsed = r.Result.CreditsUsed
+			item.Image, item.Err = decode(r.Result.Bytes())
+		}
+		items[i] = item
+	}
+	return items
+}
+
+func decode(data []byte, err error) (image.Image, error) {
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	return img, nil
+}
+
+// Render draws items into a grid.
+func Render(items []Item, opts *Options) *image.RGBA {
+	o := withDefaults(opts, len(items))
+	captions := o.Captions
+	if o.HideCaptions {
+		captions = 0
+	}
+
+	lines := 0
+	if captions&(CaptionIndex|CaptionVersion|CaptionCredits) != 0 {
+		lines++
+	}
+	if captions&CaptionPrompt != 0 {
+		lines++
+	}
+	lineHeight := glyphHeight*o.FontScale + lineGap
+	captionHeight := 0
+	if lines > 0 {
+		captionHeight = lineGap + lines*lineHeight
+	}
+
+	rows := (len(items) + o.Columns - 1) / o.Columns
+	if rows == 0 {
+		rows = 1
+	}
+	slotW := o.CellWidth + o.Padding
+	slotH := o.CellHeight + captionHeight + o.Padding
+	sheet := image.NewRGBA(image.Rect(0, 0, o.Padding+o.Columns*slotW, o.Padding+rows*slotH))
+	fillRect(sheet, sheet.Bounds(), o.Background)
+
+	for i, item := range items {
+		x := o.Padding + (i%o.Columns)*slotW
+		y := o.Padding + (i/o.Columns)*slotH
+		cell := image.Rect(x, y, x+o.CellWidth, y+o.CellHeight)
+
+		if item.Image != nil {
+			drawFitted(sheet, cell, item.Image)
+		} else {
+			drawPlaceholder(sheet, cell, item.Err, o)
+		}
+
+		ty := cell.Max.Y + lineGap
+		if head := headline(item, captions); head != "" {
+			drawText(sheet, x, ty, o.FontScale, fitText(head, o.CellWidth, o.FontScale), o.Text)
+			ty += lineHeight
+		}
+		if captions&CaptionPrompt != 0 && item.Prompt != "" {
+			prompt := strings.Join(strings.Fields(item.Prompt), " ")
+			drawText(sheet, x, ty, o.FontScale, fitText(prompt, o.CellWidth, o.FontScale), o.Text)
+		}
+	}
+	return sheet
+}
+
+// Encode renders items and writes the sheet as PNG.
+func Encode(w io.Writer, items []Item, opts *Options) error {
+	return png.Encode(w, Render(items, opts))
+}
+
+// WriteFile renders items and saves the sheet as a PNG file.
+func WriteFile(path string, items []Item, opts *Options) error {
+	var buf bytes.Buffer
+	if err := Encode(&buf, items, opts); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+func withDefaults(opts *Options, n int) Options {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Columns <= 0 {
+		o.Columns = max(1, int(math.Ceil(math.Sqrt(float64(n)))))
+	}
+	if o.CellWidth <= 0 {
+		o.CellWidth = DefaultCellWidth
+	}
+	if o.CellHeight <= 0 {
+		o.CellHeight = DefaultCellHeight
+	}
+	switch {
+	case o.Padding == 0:
+		o.Padding = DefaultPadding
+	case o.Padding < 0:
+		o.Padding = 0
+	}
+	if o.Captions == 0 {
+		o.Captions = CaptionAll
+	}
+	if o.FontScale <= 0 {
+		o.FontScale = DefaultFontScale
+	}
+	if o.Background == nil {
+		o.Background = color.White
+	}
+	if o.Text == nil {
+		o.Text = color.RGBA{0x22, 0x22, 0x22, 0xff}
+	}
+	if o.Placeholder == nil {
+		o.Placeholder = color.RGBA{0xe4, 0xe4, 0xe4, 0xff}
+	}
+	return o
+}
+
+// headline builds the first caption line, such as "#3 | v1 | 18 cr".
+func headline(item Item, captions Caption) string {
+	var parts []string
+	if captions&CaptionIndex != 0 {
+		parts = append(parts, fmt.Sprintf("#%d", item.Index))
+	}
+	if captions&CaptionVersion != 0 && item.Version != "" {
+		parts = append(parts, item.Version)
+	}
+	if captions&CaptionCredits != 0 && item.Image != nil {
+		parts = append(parts, fmt.Sprintf("%d cr", item.CreditsUsed))
+	}
+	return strings.Join(parts, " | ")
+}
+
+// drawFitted scales src into cell, centered, keeping its aspect ratio.
+// Each destination pixel averages the source pixels it covers.
+func drawFitted(dst *image.RGBA, cell image.Rectangle, src image.Image) {
+	sb := src.Bounds()
+	if sb.Empty() {
+		return
+	}
+	scale := math.Min(float64(cell.Dx())/float64(sb.Dx()), float64(cell.Dy())/float64(sb.Dy()))
+	w := max(1, int(float64(sb.Dx())*scale))
+	h := max(1, int(float64(sb.Dy())*scale))
+	ox := cell.Min.X + (cell.Dx()-w)/2
+	oy := cell.Min.Y + (cell.Dy()-h)/2
+
+	scaled := image.NewRGBA64(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		sy0 := sb.Min.Y + y*sb.Dy()/h
+		sy1 := max(sy0+1, sb.Min.Y+(y+1)*sb.Dy()/h)
+		for x := 0; x < w; x++ {
+			sx0 := sb.Min.X + x*sb.Dx()/w
+			sx1 := max(sx0+1, sb.Min.X+(x+1)*sb.Dx()/w)
+
+			var r, g, b, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a, n = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			scaled.SetRGBA64(x, y, color.RGBA64{uint16(r / n), uint16(g / n), uint16(b / n), uint16(a / n)})
+		}
+	}
+	draw.Draw(dst, image.Rect(ox, oy, ox+w, oy+h), scaled, image.Point{}, draw.Over)
+}
+
+// drawPlaceholder fills a failed cell and crosses it out.
+func drawPlaceholder(dst *image.RGBA, cell image.Rectangle, err error, o Options) {
+	fillRect(dst, cell, o.Placeholder)
+	cross := color.RGBA{0xc0, 0x39, 0x2b, 0xff}
+	for i := 0; i < cell.Dx(); i++ {
+		y := i * cell.Dy() / cell.Dx()
+		dst.Set(cell.Min.X+i, cell.Min.Y+y, cross)
+		dst.Set(cell.Max.X-1-i, cell.Min.Y+y, cross)
+	}
+
+	msg := "FAILED"
+	if err != nil {
+		msg += ": " + err.Error()
+	}
+	text := fitText(msg, cell.Dx()-2*o.Padding, o.FontScale)
+	x := cell.Min.X + (cell.Dx()-textWidth(text, o.FontScale))/2
+	y := cell.Min.Y + (cell.Dy()-glyphHeight*o.FontScale)/2
+	fillRect(dst, image.Rect(x-2, y-2, x+textWidth(text, o.FontScale)+2, y+glyphHeight*o.FontScale+2), o.Placeholder)
+	drawText(dst, x, y, o.FontScale, text, cross)
+}
+
+func fillRect(dst *image.RGBA, r image.Rectangle, c color.Color) {
+	draw.Draw(dst, r, image.NewUniform(c), image.Point{}, draw.Src)
+}
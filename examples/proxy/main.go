@@ -30,7 +30,7 @@ func main() {
 	// Example 1: HTTP Proxy
 	// =========================================================================
 	fmt.Println("=== HTTP Proxy Configuration ===")
-	fmt.Println(`
+	fmt.Print(`
 // Simple HTTP proxy
 client := reve.NewClient(apiKey,
     reve.WithHTTPProxy("http://proxy.example.com:8080"),
@@ -46,7 +46,7 @@ client := reve.NewClient(apiKey,
 	// Example 2: HTTPS Proxy
 	// =========================================================================
 	fmt.Println("=== HTTPS Proxy Configuration ===")
-	fmt.Println(`
+	fmt.Print(`
 // HTTPS proxy (same as HTTP proxy for most cases)
 client := reve.NewClient(apiKey,
     reve.WithHTTPSProxy("https://proxy.example.com:8443"),
@@ -57,7 +57,7 @@ client := reve.NewClient(apiKey,
 	// Example 3: SOCKS5 Proxy
 	// =========================================================================
 	fmt.Println("=== SOCKS5 Proxy Configuration ===")
-	fmt.Println(`
+	fmt.Print(`
 // SOCKS5 proxy without authentication
 client := reve.NewClient(apiKey,
     reve.WithSOCKS5Proxy("127.0.0.1:1080", "", ""),
@@ -73,7 +73,7 @@ client := reve.NewClient(apiKey,
 	// Example 4: Environment Proxy
 	// =========================================================================
 	fmt.Println("=== Environment Proxy Configuration ===")
-	fmt.Println(`
+	fmt.Print(`
 // Uses HTTP_PROXY, HTTPS_PROXY, NO_PROXY environment variables
 // Set in shell:
 //   export HTTP_PROXY=http://proxy:8080
@@ -89,7 +89,7 @@ client := reve.NewClient(apiKey,
 	// Example 5: Custom Transport
 	// =========================================================================
 	fmt.Println("=== Custom Transport Configuration ===")
-	fmt.Println(`
+	fmt.Print(`
 // For advanced proxy configurations, use a custom transport
 transport := &http.Transport{
     Proxy: http.ProxyURL(proxyURL),
@@ -106,7 +106,7 @@ client := reve.NewClient(apiKey,
 	// Example 6: Combining with other options
 	// =========================================================================
 	fmt.Println("=== Combined Configuration ===")
-	fmt.Println(`
+	fmt.Print(`
 // Combine proxy with other options
 client := reve.NewClient(apiKey,
     reve.WithHTTPProxy("http://proxy:8080"),
@@ -163,7 +163,7 @@ client := reve.NewClient(apiKey,
 	// Example 7: Practical usage scenarios
 	// =========================================================================
 	fmt.Println("\n=== Practical Scenarios ===")
-	fmt.Println(`
+	fmt.Print(`
 // Scenario 1: Corporate network with proxy
 client := reve.NewClient(apiKey,
     reve.WithHTTPProxy("http://corporate-proxy.internal:3128"),
@@ -3,3 +3,5 @@ module github.com/shamspias/reve-go
 go 1.25
 
 require golang.org/x/net v0.49.0
+
+require golang.org/x/text v0.33.0 // indirect
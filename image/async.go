@@ -0,0 +1,372 @@
+package image
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/shamspias/reve-go/types"
+)
+
+// Worker pool defaults.
+const (
+	DefaultWorkers      = 4
+	DefaultQueueSize    = 64
+	DefaultJobRetention = 15 * time.Minute
+)
+
+// Async errors.
+var (
+	ErrQueueFull  = errors.New("image: job queue is full")
+	ErrPoolClosed = errors.New("image: worker pool is closed")
+)
+
+// JobStatus is the state of an async job.
+type JobStatus string
+
+// Job states.
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+	JobCanceled  JobStatus = "canceled"
+)
+
+// Done returns true for terminal states.
+func (s JobStatus) Done() bool {
+	return s == JobSucceeded || s == JobFailed || s == JobCanceled
+}
+
+// Job is a handle to an image operation running on the worker pool.
+type Job struct {
+	id       string
+	ctx      context.Context
+	cancel   context.CancelFunc
+	run      func(context.Context) (*types.Result, error)
+	done     chan struct{}
+	created  time.Time
+	mu       sync.Mutex
+	status   JobStatus
+	result   *types.Result
+	err      error
+	finished time.Time
+}
+
+// ID returns the job ID.
+func (j *Job) ID() string {
+	return j.id
+}
+
+// Status returns the current state.
+func (j *Job) Status() JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status
+}
+
+// Done returns a channel that is closed when the job finishes.
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Wait blocks until the job finishes or ctx is done. Cancelling ctx
+// stops waiting but does not cancel the job.
+func (j *Job) Wait(ctx context.Context) (*types.Result, error) {
+	select {
+	case <-j.done:
+		return j.Result()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Result returns the outcome without blocking. Both values are nil
+// while the job is still queued or running.
+func (j *Job) Result() (*types.Result, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.result, j.err
+}
+
+// Cancel cancels the job. A queued job never starts; a running job has
+// its request context cancelled.
+func (j *Job) Cancel() {
+	j.cancel()
+	j.mu.Lock()
+	queued := j.status == JobQueued
+	j.mu.Unlock()
+	if queued {
+		j.finish(nil, context.Canceled)
+	}
+}
+
+// start moves a queued job to running. It returns false if the job
+// was cancelled while queued.
+func (j *Job) start() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status != JobQueued {
+		return false
+	}
+	j.status = JobRunning
+	return true
+}
+
+func (j *Job) finish(result *types.Result, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status.Done() {
+		return
+	}
+	j.result, j.err = result, err
+	j.finished = time.Now()
+	switch {
+	case err == nil:
+		j.status = JobSucceeded
+	case errors.Is(err, context.Canceled):
+		j.status = JobCanceled
+	default:
+		j.status = JobFailed
+	}
+	j.cancel()
+	close(j.done)
+}
+
+// workerPool runs jobs with a fixed number of workers and a bounded queue.
+type workerPool struct {
+	workers   int
+	queueSize int
+	retention time.Duration
+
+	startOnce sync.Once
+	queue     chan *Job
+	wg        sync.WaitGroup
+
+	mu     sync.Mutex
+	jobs   map[string]*Job
+	closed bool
+}
+
+// WithWorkerPool sets the number of workers and the queue size used by
+// the Async methods. Values below one use the defaults. Submitting to a
+// full queue fails with ErrQueueFull.
+func WithWorkerPool(workers, queueSize int) ServiceOption {
+	return func(s *Service) {
+		if workers < 1 {
+			workers = DefaultWorkers
+		}
+		if queueSize < 1 {
+			queueSize = DefaultQueueSize
+		}
+		s.pool.workers = workers
+		s.pool.queueSize = queueSize
+	}
+}
+
+// WithJobRetention sets how long finished jobs can be looked up by ID.
+func WithJobRetention(d time.Duration) ServiceOption {
+	return func(s *Service) {
+		s.pool.retention = d
+	}
+}
+
+func newWorkerPool() *workerPool {
+	return &workerPool{
+		workers:   DefaultWorkers,
+		queueSize: DefaultQueueSize,
+		retention: DefaultJobRetention,
+		jobs:      make(map[string]*Job),
+	}
+}
+
+func (p *workerPool) start() {
+	p.startOnce.Do(func() {
+		p.queue = make(chan *Job, p.queueSize)
+		for i := 0; i < p.workers; i++ {
+			p.wg.Add(1)
+			go p.work()
+		}
+	})
+}
+
+func (p *workerPool) work() {
+	defer p.wg.Done()
+	for job := range p.queue {
+		if !job.start() {
+			continue
+		}
+		result, err := job.run(job.ctx)
+		if err != nil && job.ctx.Err() != nil {
+			err = job.ctx.Err()
+		}
+		job.finish(result, err)
+	}
+}
+
+func (p *workerPool) submit(ctx context.Context, run func(context.Context) (*types.Result, error)) (*Job, error) {
+	p.start()
+
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	job := &Job{
+		id:      newJobID(),
+		ctx:     jobCtx,
+		cancel:  cancel,
+		run:     run,
+		done:    make(chan struct{}),
+		created: time.Now(),
+		status:  JobQueued,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		cancel()
+		return nil, ErrPoolClosed
+	}
+	select {
+	case p.queue <- job:
+	default:
+		cancel()
+		return nil, ErrQueueFull
+	}
+	p.prune()
+	p.jobs[job.id] = job
+	return job, nil
+}
+
+// prune drops finished jobs older than the retention period.
+// The caller must hold p.mu.
+func (p *workerPool) prune() {
+	cutoff := time.Now().Add(-p.retention)
+	for id, job := range p.jobs {
+		job.mu.Lock()
+		expired := job.status.Done() && job.finished.Before(cutoff)
+		job.mu.Unlock()
+		if expired {
+			delete(p.jobs, id)
+		}
+	}
+}
+
+func (p *workerPool) job(id string) (*Job, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	job, ok := p.jobs[id]
+	return job, ok
+}
+
+func (p *workerPool) close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	for _, job := range p.jobs {
+		if job.Status() == JobQueued {
+			job.Cancel()
+		}
+	}
+	started := p.queue != nil
+	if started {
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	if started {
+		p.wg.Wait()
+	}
+}
+
+func newJobID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return "job_" + hex.EncodeToString(b)
+}
+
+// CreateAsync queues a create request on the worker pool and returns
+// immediately. Parameters are validated and copied before queueing, so
+// params may be reused once CreateAsync returns. The job keeps running
+// after ctx is cancelled; use Job.Cancel to stop it.
+//
+// Example:
+//
+//	job, err := client.Images.CreateAsync(ctx, &image.CreateParams{
+//		Prompt:          "A detailed city map",
+//		TestTimeScaling: 10,
+//	})
+//	if errors.Is(err, image.ErrQueueFull) {
+//		http.Error(w, "busy", http.StatusServiceUnavailable)
+//		return
+//	}
+//	fmt.Fprintln(w, job.ID())
+//
+//	// Later:
+//	job, _ = client.Images.Job(id)
+//	result, err := job.Wait(ctx)
+func (s *Service) CreateAsync(ctx context.Context, params *CreateParams, opts ...RequestOption) (*Job, error) {
+	if params == nil {
+		return nil, (&CreateParams{}).Validate()
+	}
+	p := *params
+	p.Postprocess = slices.Clone(p.Postprocess)
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	opts = slices.Clone(opts)
+	return s.pool.submit(ctx, func(ctx context.Context) (*types.Result, error) {
+		return s.Create(ctx, &p, opts...)
+	})
+}
+
+// EditAsync queues an edit request on the worker pool.
+// See CreateAsync for details.
+func (s *Service) EditAsync(ctx context.Context, params *EditParams, opts ...RequestOption) (*Job, error) {
+	if params == nil {
+		return nil, (&EditParams{}).Validate()
+	}
+	p := *params
+	p.Postprocess = slices.Clone(p.Postprocess)
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	opts = slices.Clone(opts)
+	return s.pool.submit(ctx, func(ctx context.Context) (*types.Result, error) {
+		return s.Edit(ctx, &p, opts...)
+	})
+}
+
+// RemixAsync queues a remix request on the worker pool.
+// See CreateAsync for details.
+func (s *Service) RemixAsync(ctx context.Context, params *RemixParams, opts ...RequestOption) (*Job, error) {
+	if params == nil {
+		return nil, (&RemixParams{}).Validate()
+	}
+	p := *params
+	p.ReferenceImages = slices.Clone(p.ReferenceImages)
+	p.Postprocess = slices.Clone(p.Postprocess)
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	opts = slices.Clone(opts)
+	return s.pool.submit(ctx, func(ctx context.Context) (*types.Result, error) {
+		return s.Remix(ctx, &p, opts...)
+	})
+}
+
+// Job returns a job submitted with one of the Async methods.
+// Finished jobs are kept for the retention period (default 15 minutes).
+func (s *Service) Job(id string) (*Job, bool) {
+	return s.pool.job(id)
+}
+
+// Close stops the worker pool. Queued jobs are cancelled and running
+// jobs are allowed to finish.
+func (s *Service) Close() {
+	s.pool.close()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 
+	"github.com/shamspias/reve-go/output"
 	"github.com/shamspias/reve-go/types"
 )
 
@@ -16,6 +17,14 @@ type BatchConfig struct {
 	// StopOnError stops on first error.
 	// Default: false
 	StopOnError bool
+
+	// Sink receives each successful result as it completes.
+	// Default: nil (results are only returned)
+	Sink output.Sink
+
+	// Naming names results stored in Sink.
+	// Default: output.DefaultTemplate
+	Naming output.Template
 }
 
 // DefaultBatchConfig returns default configuration.
@@ -36,6 +45,9 @@ type BatchResult struct {
 
 	// Error is the error if failed.
 	Error error
+
+	// Key is where the result was stored when BatchConfig.Sink is set.
+	Key string
 }
 
 // BatchCreate executes multiple create requests concurrently.
@@ -97,7 +109,11 @@ func (s *Service) BatchCreate(ctx context.Context, params []*CreateParams, confi
 			}
 
 			result, err := s.Create(ctx, req)
-			results[idx] = BatchResult{Index: idx, Result: result, Error: err}
+			var key string
+			if err == nil {
+				key, err = config.store(ctx, types.EndpointCreate, idx, req.Prompt, req.Breadcrumb, result)
+			}
+			results[idx] = BatchResult{Index: idx, Result: result, Error: err, Key: key}
 
 			if err != nil && config.StopOnError {
 				stopMu.Lock()
@@ -161,7 +177,11 @@ func (s *Service) BatchEdit(ctx context.Context, params []*EditParams, config *B
 			}
 
 			result, err := s.Edit(ctx, req)
-			results[idx] = BatchResult{Index: idx, Result: result, Error: err}
+			var key string
+			if err == nil {
+				key, err = config.store(ctx, types.EndpointEdit, idx, req.Instruction, req.Breadcrumb, result)
+			}
+			results[idx] = BatchResult{Index: idx, Result: result, Error: err, Key: key}
 
 			if err != nil && config.StopOnError {
 				stopMu.Lock()
@@ -214,7 +234,11 @@ func (s *Service) BatchRemix(ctx context.Context, params []*RemixParams, config
 			}
 
 			result, err := s.Remix(ctx, req)
-			results[idx] = BatchResult{Index: idx, Result: result, Error: err}
+			var key string
+			if err == nil {
+				key, err = config.store(ctx, types.EndpointRemix, idx, req.Prompt, req.Breadcrumb, result)
+			}
+			results[idx] = BatchResult{Index: idx, Result: result, Error: err, Key: key}
 
 			if err != nil && config.StopOnError {
 				stopMu.Lock()
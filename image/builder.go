@@ -0,0 +1,393 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/shamspias/reve-go/internal/validator"
+	"github.com/shamspias/reve-go/types"
+)
+
+// builder holds the settings shared by the request builders.
+type builder struct {
+	aspectRatio types.AspectRatio
+	version     types.ModelVersion
+	postprocess []types.Postprocess
+	scaling     float64
+	breadcrumb  string
+	errs        validator.Errors
+}
+
+// Err returns the errors recorded while building as a
+// *types.ValidationError, or nil.
+func (b *builder) Err() error {
+	return b.errs.Err()
+}
+
+func (b *builder) setAspect(ratio types.AspectRatio) {
+	b.aspectRatio = ratio
+	b.errs.Check("aspect_ratio", validator.ValidateAspectRatio(string(ratio)))
+}
+
+func (b *builder) setVersion(v types.ModelVersion, endpoint types.Endpoint) {
+	b.version = v
+	b.errs.Check("version", validator.ValidateVersion(v, endpoint))
+}
+
+func (b *builder) setScaling(scaling float64) {
+	b.scaling = scaling
+	b.errs.Check("test_time_scaling", validator.ValidateScaling(scaling))
+}
+
+func (b *builder) addPostprocess(pp types.Postprocess) {
+	b.errs.Check(postprocessPath(len(b.postprocess), pp), pp.Validate())
+	b.postprocess = append(b.postprocess, pp)
+}
+
+// CreateBuilder builds CreateParams fluently.
+//
+// Each setter validates its input as it is applied. The errors are
+// reported together, under the field at fault, by Err, Params, Do and
+// DoRaw as a *types.ValidationError.
+//
+// Example:
+//
+//	result, err := image.NewCreate("A lighthouse in a storm").
+//		Aspect(types.Ratio16x9).
+//		Upscale(2).
+//		Breadcrumb("campaign-42").
+//		Do(ctx, client.Images)
+type CreateBuilder struct {
+	builder
+	prompt string
+}
+
+// NewCreate starts building a create request.
+func NewCreate(prompt string) *CreateBuilder {
+	b := &CreateBuilder{prompt: prompt}
+	b.errs.Check("prompt", validator.ValidatePrompt(prompt))
+	return b
+}
+
+// Aspect sets the aspect ratio.
+func (b *CreateBuilder) Aspect(ratio types.AspectRatio) *CreateBuilder {
+	b.setAspect(ratio)
+	return b
+}
+
+// Version sets the model version.
+func (b *CreateBuilder) Version(v types.ModelVersion) *CreateBuilder {
+	b.setVersion(v, types.EndpointCreate)
+	return b
+}
+
+// Fast selects the fast model variant.
+func (b *CreateBuilder) Fast() *CreateBuilder {
+	return b.Version(types.VersionLatestFast)
+}
+
+// Scaling sets test time scaling (1-15).
+func (b *CreateBuilder) Scaling(scaling float64) *CreateBuilder {
+	b.setScaling(scaling)
+	return b
+}
+
+// Upscale adds an upscale postprocessing step.
+func (b *CreateBuilder) Upscale(factor int) *CreateBuilder {
+	b.addPostprocess(types.Upscale(factor))
+	return b
+}
+
+// RemoveBackground adds a background removal postprocessing step.
+func (b *CreateBuilder) RemoveBackground() *CreateBuilder {
+	b.addPostprocess(types.RemoveBackground())
+	return b
+}
+
+// Breadcrumb sets the tracking ID.
+func (b *CreateBuilder) Breadcrumb(id string) *CreateBuilder {
+	b.breadcrumb = id
+	return b
+}
+
+// Params returns a copy of the built parameters after full validation.
+func (b *CreateBuilder) Params() (*CreateParams, error) {
+	if err := b.Err(); err != nil {
+		return nil, err
+	}
+	p := &CreateParams{
+		Prompt:          b.prompt,
+		AspectRatio:     b.aspectRatio,
+		Version:         b.version,
+		Postprocess:     slices.Clone(b.postprocess),
+		TestTimeScaling: b.scaling,
+		Breadcrumb:      b.breadcrumb,
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Do executes the request with svc.
+func (b *CreateBuilder) Do(ctx context.Context, svc *Service, opts ...RequestOption) (*types.Result, error) {
+	p, err := b.Params()
+	if err != nil {
+		return nil, err
+	}
+	return svc.Create(ctx, p, opts...)
+}
+
+// DoRaw executes the request with svc and returns raw bytes.
+func (b *CreateBuilder) DoRaw(ctx context.Context, svc *Service, format types.OutputFormat, opts ...RequestOption) (*types.RawResult, error) {
+	p, err := b.Params()
+	if err != nil {
+		return nil, err
+	}
+	return svc.CreateRaw(ctx, p, format, opts...)
+}
+
+// EditBuilder builds EditParams fluently.
+//
+// Example:
+//
+//	img, _ := types.NewImageFromFile("photo.jpg")
+//	result, err := image.NewEdit("Make it look like a watercolor", img).
+//		Fast().
+//		Do(ctx, client.Images)
+type EditBuilder struct {
+	builder
+	instruction    string
+	referenceImage string
+}
+
+// NewEdit starts building an edit request for img.
+func NewEdit(instruction string, img *types.Image) *EditBuilder {
+	b := &EditBuilder{instruction: instruction}
+	if img != nil {
+		b.referenceImage = img.Base64()
+	}
+	b.errs.Check("edit_instruction", validator.ValidateInstruction(instruction))
+	b.errs.Check("reference_image", validator.ValidateReferenceImage(b.referenceImage))
+	return b
+}
+
+// Aspect sets the aspect ratio.
+func (b *EditBuilder) Aspect(ratio types.AspectRatio) *EditBuilder {
+	b.setAspect(ratio)
+	return b
+}
+
+// Version sets the model version.
+func (b *EditBuilder) Version(v types.ModelVersion) *EditBuilder {
+	b.setVersion(v, types.EndpointEdit)
+	return b
+}
+
+// Fast selects the fast model variant.
+func (b *EditBuilder) Fast() *EditBuilder {
+	return b.Version(types.VersionLatestFast)
+}
+
+// Scaling sets test time scaling (1-15).
+func (b *EditBuilder) Scaling(scaling float64) *EditBuilder {
+	b.setScaling(scaling)
+	return b
+}
+
+// Upscale adds an upscale postprocessing step.
+func (b *EditBuilder) Upscale(factor int) *EditBuilder {
+	b.addPostprocess(types.Upscale(factor))
+	return b
+}
+
+// RemoveBackground adds a background removal postprocessing step.
+func (b *EditBuilder) RemoveBackground() *EditBuilder {
+	b.addPostprocess(types.RemoveBackground())
+	return b
+}
+
+// Breadcrumb sets the tracking ID.
+func (b *EditBuilder) Breadcrumb(id string) *EditBuilder {
+	b.breadcrumb = id
+	return b
+}
+
+// Params returns a copy of the built parameters after full validation.
+func (b *EditBuilder) Params() (*EditParams, error) {
+	if err := b.Err(); err != nil {
+		return nil, err
+	}
+	p := &EditParams{
+		Instruction:     b.instruction,
+		ReferenceImage:  b.referenceImage,
+		AspectRatio:     b.aspectRatio,
+		Version:         b.version,
+		Postprocess:     slices.Clone(b.postprocess),
+		TestTimeScaling: b.scaling,
+		Breadcrumb:      b.breadcrumb,
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Do executes the request with svc.
+func (b *EditBuilder) Do(ctx context.Context, svc *Service, opts ...RequestOption) (*types.Result, error) {
+	p, err := b.Params()
+	if err != nil {
+		return nil, err
+	}
+	return svc.Edit(ctx, p, opts...)
+}
+
+// DoRaw executes the request with svc and returns raw bytes.
+func (b *EditBuilder) DoRaw(ctx context.Context, svc *Service, format types.OutputFormat, opts ...RequestOption) (*types.RawResult, error) {
+	p, err := b.Params()
+	if err != nil {
+		return nil, err
+	}
+	return svc.EditRaw(ctx, p, format, opts...)
+}
+
+// RemixBuilder builds RemixParams fluently.
+//
+// Reference images are indexed in the order they are added, and
+// AddReference returns the tag to use in the prompt.
+//
+// Example:
+//
+//	b := image.NewRemix("")
+//	style := b.AddReference(styleImg)
+//	scene := b.AddReference(sceneImg)
+//	result, err := b.Prompt("Paint " + scene + " in the style of " + style).
+//		Aspect(types.Ratio1x1).
+//		Do(ctx, client.Images)
+type RemixBuilder struct {
+	builder
+	prompt          string
+	referenceImages []string
+	refs            map[*types.Image]int
+}
+
+// NewRemix starts building a remix request.
+// The prompt may be empty and set later with Prompt.
+func NewRemix(prompt string) *RemixBuilder {
+	return &RemixBuilder{prompt: prompt}
+}
+
+// Prompt sets the prompt text.
+func (b *RemixBuilder) Prompt(prompt string) *RemixBuilder {
+	b.prompt = prompt
+	return b
+}
+
+// AddReference attaches img and returns its <img>N</img> tag.
+// Adding the same image twice returns the same tag.
+func (b *RemixBuilder) AddReference(img *types.Image) string {
+	if idx, ok := b.refs[img]; ok {
+		return types.Ref(idx)
+	}
+	if img == nil {
+		b.errs.Check(fmt.Sprintf("reference_images[%d]", len(b.referenceImages)), validator.ErrEmptyReferenceImage)
+		return ""
+	}
+	if len(b.referenceImages) >= validator.MaxReferenceImages {
+		b.errs.Check("reference_images", validator.ErrTooManyReferenceImages)
+		return ""
+	}
+	if b.refs == nil {
+		b.refs = make(map[*types.Image]int)
+	}
+	idx := len(b.referenceImages)
+	b.referenceImages = append(b.referenceImages, img.Base64())
+	b.refs[img] = idx
+	return types.Ref(idx)
+}
+
+// Reference attaches img without returning its tag.
+func (b *RemixBuilder) Reference(img *types.Image) *RemixBuilder {
+	b.AddReference(img)
+	return b
+}
+
+// Aspect sets the aspect ratio.
+func (b *RemixBuilder) Aspect(ratio types.AspectRatio) *RemixBuilder {
+	b.setAspect(ratio)
+	return b
+}
+
+// Version sets the model version.
+func (b *RemixBuilder) Version(v types.ModelVersion) *RemixBuilder {
+	b.setVersion(v, types.EndpointRemix)
+	return b
+}
+
+// Fast selects the fast model variant.
+func (b *RemixBuilder) Fast() *RemixBuilder {
+	return b.Version(types.VersionLatestFast)
+}
+
+// Scaling sets test time scaling (1-15).
+func (b *RemixBuilder) Scaling(scaling float64) *RemixBuilder {
+	b.setScaling(scaling)
+	return b
+}
+
+// Upscale adds an upscale postprocessing step.
+func (b *RemixBuilder) Upscale(factor int) *RemixBuilder {
+	b.addPostprocess(types.Upscale(factor))
+	return b
+}
+
+// RemoveBackground adds a background removal postprocessing step.
+func (b *RemixBuilder) RemoveBackground() *RemixBuilder {
+	b.addPostprocess(types.RemoveBackground())
+	return b
+}
+
+// Breadcrumb sets the tracking ID.
+func (b *RemixBuilder) Breadcrumb(id string) *RemixBuilder {
+	b.breadcrumb = id
+	return b
+}
+
+// Params returns a copy of the built parameters after full validation.
+func (b *RemixBuilder) Params() (*RemixParams, error) {
+	if err := b.Err(); err != nil {
+		return nil, err
+	}
+	p := &RemixParams{
+		Prompt:          b.prompt,
+		ReferenceImages: slices.Clone(b.referenceImages),
+		AspectRatio:     b.aspectRatio,
+		Version:         b.version,
+		Postprocess:     slices.Clone(b.postprocess),
+		TestTimeScaling: b.scaling,
+		Breadcrumb:      b.breadcrumb,
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Do executes the request with svc.
+func (b *RemixBuilder) Do(ctx context.Context, svc *Service, opts ...RequestOption) (*types.Result, error) {
+	p, err := b.Params()
+	if err != nil {
+		return nil, err
+	}
+	return svc.Remix(ctx, p, opts...)
+}
+
+// DoRaw executes the request with svc and returns raw bytes.
+func (b *RemixBuilder) DoRaw(ctx context.Context, svc *Service, format types.OutputFormat, opts ...RequestOption) (*types.RawResult, error) {
+	p, err := b.Params()
+	if err != nil {
+		return nil, err
+	}
+	return svc.RemixRaw(ctx, p, format, opts...)
+}
@@ -0,0 +1,44 @@
+package image
+
+import "github.com/shamspias/reve-go/types"
+
+// Versions returns types.Versions(endpoint), with Deprecated also set
+// for versions this service's responses have reported as deprecated.
+func (s *Service) Versions(endpoint types.Endpoint) []types.ModelInfo {
+	versions := types.Versions(endpoint)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range versions {
+		if s.deprecated[versions[i].Version] {
+			versions[i].Deprecated = true
+		}
+	}
+	return versions
+}
+
+// LookupVersion returns types.LookupVersion(v), with Deprecated also set
+// if this service's responses have reported v as deprecated.
+func (s *Service) LookupVersion(v types.ModelVersion) (types.ModelInfo, bool) {
+	info, ok := types.LookupVersion(v)
+	if ok {
+		s.mu.Lock()
+		info.Deprecated = info.Deprecated || s.deprecated[v]
+		s.mu.Unlock()
+	}
+	return info, ok
+}
+
+// markDeprecated records that the API reported v as deprecated. It
+// returns false if v was already recorded.
+func (s *Service) markDeprecated(v types.ModelVersion) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.deprecated[v] {
+		return false
+	}
+	if s.deprecated == nil {
+		s.deprecated = make(map[types.ModelVersion]bool)
+	}
+	s.deprecated[v] = true
+	return true
+}
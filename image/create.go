@@ -3,6 +3,7 @@ package image
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 
 	"github.com/shamspias/reve-go/internal/transport"
@@ -36,22 +37,15 @@ type CreateParams struct {
 }
 
 // Validate validates the parameters.
+// All problems are reported together in a *types.ValidationError.
 func (p *CreateParams) Validate() error {
-	if err := validator.ValidatePrompt(p.Prompt); err != nil {
-		return err
-	}
-	if err := validator.ValidateAspectRatio(string(p.AspectRatio)); err != nil {
-		return err
-	}
-	if err := validator.ValidateScaling(p.TestTimeScaling); err != nil {
-		return err
-	}
-	for _, pp := range p.Postprocess {
-		if err := pp.Validate(); err != nil {
-			return err
-		}
-	}
-	return nil
+	var errs validator.Errors
+	errs.Check("prompt", validator.ValidatePrompt(p.Prompt))
+	errs.Check("aspect_ratio", validator.ValidateAspectRatio(string(p.AspectRatio)))
+	errs.Check("version", validator.ValidateVersion(p.Version, types.EndpointCreate))
+	errs.Check("test_time_scaling", validator.ValidateScaling(p.TestTimeScaling))
+	validatePostprocess(&errs, p.Postprocess)
+	return errs.Err()
 }
 
 // Create generates an image from a text description.
@@ -66,20 +60,24 @@ func (p *CreateParams) Validate() error {
 //		log.Fatal(err)
 //	}
 //	err = result.SaveTo("lake.png")
-func (s *Service) Create(ctx context.Context, params *CreateParams) (*types.Result, error) {
+func (s *Service) Create(ctx context.Context, params *CreateParams, opts ...RequestOption) (*types.Result, error) {
 	if params == nil {
-		return nil, validator.ErrEmptyPrompt
+		return nil, (&CreateParams{}).Validate()
 	}
 	if err := params.Validate(); err != nil {
 		return nil, err
 	}
 
-	resp, err := s.transport.Do(ctx, &transport.Request{
+	body := *params
+	body.Version = s.resolveVersion(types.EndpointCreate, params.Version)
+
+	req := newRequest(&transport.Request{
 		Method:     http.MethodPost,
 		Path:       "/v1/image/create",
-		Body:       params,
+		Body:       &body,
 		Breadcrumb: params.Breadcrumb,
-	})
+	}, opts)
+	resp, err := s.transport.Do(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -88,8 +86,12 @@ func (s *Service) Create(ctx context.Context, params *CreateParams) (*types.Resu
 	if err := json.Unmarshal(resp.Body, &result); err != nil {
 		return nil, err
 	}
+	result.KeyLabel = resp.KeyLabel
+	result.BaseURL = resp.BaseURL
+	result.Deprecated = resp.Deprecated
+	s.observeVersion(types.EndpointCreate, body.Version, result.Version, resp.Deprecated)
 
-	return &result, nil
+	return &result, s.store(ctx, types.EndpointCreate, params.Prompt, req.Breadcrumb, &result)
 }
 
 // CreateRaw generates an image and returns raw bytes.
@@ -103,30 +105,19 @@ func (s *Service) Create(ctx context.Context, params *CreateParams) (*types.Resu
 //		log.Fatal(err)
 //	}
 //	err = result.SaveTo("sunset.png")
-func (s *Service) CreateRaw(ctx context.Context, params *CreateParams, format types.OutputFormat) (*types.RawResult, error) {
-	if params == nil {
-		return nil, validator.ErrEmptyPrompt
-	}
-	if err := params.Validate(); err != nil {
+func (s *Service) CreateRaw(ctx context.Context, params *CreateParams, format types.OutputFormat, opts ...RequestOption) (*types.RawResult, error) {
+	req, body, err := s.createRawRequest(params, format, opts)
+	if err != nil {
 		return nil, err
 	}
-
-	if format == "" || format == types.FormatJSON {
-		format = types.FormatPNG
-	}
-
-	resp, err := s.transport.DoRaw(ctx, &transport.Request{
-		Method:     http.MethodPost,
-		Path:       "/v1/image/create",
-		Body:       params,
-		Accept:     string(format),
-		Breadcrumb: params.Breadcrumb,
-	})
+	resp, err := s.transport.DoRaw(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
-	return &types.RawResult{
+	s.observeVersion(types.EndpointCreate, body.Version, resp.Version, resp.Deprecated)
+
+	raw := &types.RawResult{
 		Data:             resp.Data,
 		ContentType:      resp.ContentType,
 		Version:          resp.Version,
@@ -134,5 +125,56 @@ func (s *Service) CreateRaw(ctx context.Context, params *CreateParams, format ty
 		RequestID:        resp.RequestID,
 		CreditsUsed:      resp.CreditsUsed,
 		CreditsRemaining: resp.CreditsRemaining,
-	}, nil
+		KeyLabel:         resp.KeyLabel,
+		BaseURL:          resp.BaseURL,
+		Deprecated:       resp.Deprecated,
+	}
+	return raw, s.storeRaw(ctx, types.EndpointCreate, params.Prompt, req.Breadcrumb, raw)
+}
+
+// CreateTo generates an image and copies the raw bytes into w as they arrive,
+// without holding the image in memory. The result carries the metadata
+// from the response headers. Results are not stored in the client's sink.
+// Nothing is written to w when the request fails with an error status.
+//
+// Example:
+//
+//	f, _ := os.Create("sunset.png")
+//	defer f.Close()
+//	result, err := client.Images.CreateTo(ctx, &image.CreateParams{
+//		Prompt: "A sunset over the ocean",
+//	}, types.FormatPNG, f)
+func (s *Service) CreateTo(ctx context.Context, params *CreateParams, format types.OutputFormat, w io.Writer, opts ...RequestOption) (*types.StreamResult, error) {
+	req, body, err := s.createRawRequest(params, format, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.stream(ctx, types.EndpointCreate, body.Version, req, w)
+}
+
+// createRawRequest validates params and builds the request for a raw
+// response in format.
+func (s *Service) createRawRequest(params *CreateParams, format types.OutputFormat, opts []RequestOption) (*transport.Request, *CreateParams, error) {
+	if params == nil {
+		return nil, nil, (&CreateParams{}).Validate()
+	}
+	if err := params.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	if format == "" || format == types.FormatJSON {
+		format = types.FormatPNG
+	}
+
+	body := *params
+	body.Version = s.resolveVersion(types.EndpointCreate, params.Version)
+
+	req := newRequest(&transport.Request{
+		Method:     http.MethodPost,
+		Path:       "/v1/image/create",
+		Body:       &body,
+		Accept:     string(format),
+		Breadcrumb: params.Breadcrumb,
+	}, opts)
+	return req, &body, nil
 }
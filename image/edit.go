@@ -3,6 +3,7 @@ package image
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 
 	"github.com/shamspias/reve-go/internal/transport"
@@ -39,25 +40,16 @@ type EditParams struct {
 }
 
 // Validate validates the parameters.
+// All problems are reported together in a *types.ValidationError.
 func (p *EditParams) Validate() error {
-	if err := validator.ValidateInstruction(p.Instruction); err != nil {
-		return err
-	}
-	if err := validator.ValidateReferenceImage(p.ReferenceImage); err != nil {
-		return err
-	}
-	if err := validator.ValidateAspectRatio(string(p.AspectRatio)); err != nil {
-		return err
-	}
-	if err := validator.ValidateScaling(p.TestTimeScaling); err != nil {
-		return err
-	}
-	for _, pp := range p.Postprocess {
-		if err := pp.Validate(); err != nil {
-			return err
-		}
-	}
-	return nil
+	var errs validator.Errors
+	errs.Check("edit_instruction", validator.ValidateInstruction(p.Instruction))
+	errs.Check("reference_image", validator.ValidateReferenceImage(p.ReferenceImage))
+	errs.Check("aspect_ratio", validator.ValidateAspectRatio(string(p.AspectRatio)))
+	errs.Check("version", validator.ValidateVersion(p.Version, types.EndpointEdit))
+	errs.Check("test_time_scaling", validator.ValidateScaling(p.TestTimeScaling))
+	validatePostprocess(&errs, p.Postprocess)
+	return errs.Err()
 }
 
 // Edit modifies an image based on text instructions.
@@ -73,20 +65,24 @@ func (p *EditParams) Validate() error {
 //		log.Fatal(err)
 //	}
 //	err = result.SaveTo("watercolor.png")
-func (s *Service) Edit(ctx context.Context, params *EditParams) (*types.Result, error) {
+func (s *Service) Edit(ctx context.Context, params *EditParams, opts ...RequestOption) (*types.Result, error) {
 	if params == nil {
-		return nil, validator.ErrEmptyInstruction
+		return nil, (&EditParams{}).Validate()
 	}
 	if err := params.Validate(); err != nil {
 		return nil, err
 	}
 
-	resp, err := s.transport.Do(ctx, &transport.Request{
+	body := *params
+	body.Version = s.resolveVersion(types.EndpointEdit, params.Version)
+
+	req := newRequest(&transport.Request{
 		Method:     http.MethodPost,
 		Path:       "/v1/image/edit",
-		Body:       params,
+		Body:       &body,
 		Breadcrumb: params.Breadcrumb,
-	})
+	}, opts)
+	resp, err := s.transport.Do(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -95,8 +91,12 @@ func (s *Service) Edit(ctx context.Context, params *EditParams) (*types.Result,
 	if err := json.Unmarshal(resp.Body, &result); err != nil {
 		return nil, err
 	}
+	result.KeyLabel = resp.KeyLabel
+	result.BaseURL = resp.BaseURL
+	result.Deprecated = resp.Deprecated
+	s.observeVersion(types.EndpointEdit, body.Version, result.Version, resp.Deprecated)
 
-	return &result, nil
+	return &result, s.store(ctx, types.EndpointEdit, params.Instruction, req.Breadcrumb, &result)
 }
 
 // EditRaw modifies an image and returns raw bytes.
@@ -109,30 +109,19 @@ func (s *Service) Edit(ctx context.Context, params *EditParams) (*types.Result,
 //		ReferenceImage: img.Base64(),
 //		Version:        types.VersionLatestFast,
 //	}, types.FormatJPEG)
-func (s *Service) EditRaw(ctx context.Context, params *EditParams, format types.OutputFormat) (*types.RawResult, error) {
-	if params == nil {
-		return nil, validator.ErrEmptyInstruction
-	}
-	if err := params.Validate(); err != nil {
+func (s *Service) EditRaw(ctx context.Context, params *EditParams, format types.OutputFormat, opts ...RequestOption) (*types.RawResult, error) {
+	req, body, err := s.editRawRequest(params, format, opts)
+	if err != nil {
 		return nil, err
 	}
-
-	if format == "" || format == types.FormatJSON {
-		format = types.FormatPNG
-	}
-
-	resp, err := s.transport.DoRaw(ctx, &transport.Request{
-		Method:     http.MethodPost,
-		Path:       "/v1/image/edit",
-		Body:       params,
-		Accept:     string(format),
-		Breadcrumb: params.Breadcrumb,
-	})
+	resp, err := s.transport.DoRaw(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
-	return &types.RawResult{
+	s.observeVersion(types.EndpointEdit, body.Version, resp.Version, resp.Deprecated)
+
+	raw := &types.RawResult{
 		Data:             resp.Data,
 		ContentType:      resp.ContentType,
 		Version:          resp.Version,
@@ -140,5 +129,57 @@ func (s *Service) EditRaw(ctx context.Context, params *EditParams, format types.
 		RequestID:        resp.RequestID,
 		CreditsUsed:      resp.CreditsUsed,
 		CreditsRemaining: resp.CreditsRemaining,
-	}, nil
+		KeyLabel:         resp.KeyLabel,
+		BaseURL:          resp.BaseURL,
+		Deprecated:       resp.Deprecated,
+	}
+	return raw, s.storeRaw(ctx, types.EndpointEdit, params.Instruction, req.Breadcrumb, raw)
+}
+
+// EditTo modifies an image and copies the raw bytes into w as they arrive,
+// without holding the image in memory. The result carries the metadata
+// from the response headers. Results are not stored in the client's sink.
+// Nothing is written to w when the request fails with an error status.
+//
+// Example:
+//
+//	f, _ := os.Create("vintage.jpg")
+//	defer f.Close()
+//	result, err := client.Images.EditTo(ctx, &image.EditParams{
+//		Instruction:    "Add vintage filter",
+//		ReferenceImage: img.Base64(),
+//	}, types.FormatJPEG, f)
+func (s *Service) EditTo(ctx context.Context, params *EditParams, format types.OutputFormat, w io.Writer, opts ...RequestOption) (*types.StreamResult, error) {
+	req, body, err := s.editRawRequest(params, format, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.stream(ctx, types.EndpointEdit, body.Version, req, w)
+}
+
+// editRawRequest validates params and builds the request for a raw
+// response in format.
+func (s *Service) editRawRequest(params *EditParams, format types.OutputFormat, opts []RequestOption) (*transport.Request, *EditParams, error) {
+	if params == nil {
+		return nil, nil, (&EditParams{}).Validate()
+	}
+	if err := params.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	if format == "" || format == types.FormatJSON {
+		format = types.FormatPNG
+	}
+
+	body := *params
+	body.Version = s.resolveVersion(types.EndpointEdit, params.Version)
+
+	req := newRequest(&transport.Request{
+		Method:     http.MethodPost,
+		Path:       "/v1/image/edit",
+		Body:       &body,
+		Accept:     string(format),
+		Breadcrumb: params.Breadcrumb,
+	}, opts)
+	return req, &body, nil
 }
@@ -0,0 +1,309 @@
+package image
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shamspias/reve-go/types"
+)
+
+// PinMode controls how a VersionPinner treats alias versions.
+type PinMode int
+
+// Pin modes.
+const (
+	// PinRecord records the concrete versions that aliases resolve to
+	// but sends requests unchanged.
+	PinRecord PinMode = iota
+
+	// PinEnforce rewrites alias versions to their pinned concrete
+	// version so that results stay reproducible.
+	PinEnforce
+)
+
+// DriftEvent reports that the server resolved an alias to a version
+// other than the pinned one.
+type DriftEvent struct {
+	Endpoint types.Endpoint
+	Alias    types.ModelVersion
+	Pinned   types.ModelVersion
+	Served   types.ModelVersion
+}
+
+// String returns a readable description.
+func (e DriftEvent) String() string {
+	return fmt.Sprintf("%s %s resolved to %s, pinned %s", e.Endpoint, e.Alias, e.Served, e.Pinned)
+}
+
+// VersionPinner records and optionally enforces the concrete model
+// versions that "latest" and "latest-fast" resolve to per endpoint.
+//
+// Drift is only observable while requests reach the server as aliases.
+// In PinEnforce mode pinned requests carry the concrete version, so set a
+// probe interval with SetProbeInterval to have one request per alias and
+// interval sent as the alias; drift is reported from its response.
+//
+// Example:
+//
+//	pinner := image.NewVersionPinner(image.PinEnforce, func(e image.DriftEvent) {
+//		log.Printf("model drift: %s", e)
+//	})
+//	_ = pinner.LoadFile("reve.lock.json")
+//	pinner.SetProbeInterval(24 * time.Hour)
+//
+//	client := reve.NewClient(apiKey, reve.WithVersionPinning(pinner))
+//	// ... make requests ...
+//	_ = pinner.SaveFile("reve.lock.json")
+type VersionPinner struct {
+	mu      sync.Mutex
+	mode    PinMode
+	pins    map[types.Endpoint]map[types.ModelVersion]types.ModelVersion
+	onDrift func(DriftEvent)
+
+	probeEvery time.Duration
+	probed     map[types.Endpoint]map[types.ModelVersion]time.Time
+}
+
+// NewVersionPinner creates a pinner. onDrift may be nil.
+func NewVersionPinner(mode PinMode, onDrift func(DriftEvent)) *VersionPinner {
+	return &VersionPinner{
+		mode:    mode,
+		pins:    make(map[types.Endpoint]map[types.ModelVersion]types.ModelVersion),
+		onDrift: onDrift,
+		probed:  make(map[types.Endpoint]map[types.ModelVersion]time.Time),
+	}
+}
+
+// Mode returns the pin mode.
+func (p *VersionPinner) Mode() PinMode {
+	return p.mode
+}
+
+// SetProbeInterval makes a PinEnforce pinner send a pinned alias
+// unchanged at most once per interval for each endpoint, so that the
+// server's resolution can be compared with the pin. The probing request
+// is served by whatever version the alias currently resolves to; the pin
+// itself is kept. Zero, the default, disables probing.
+func (p *VersionPinner) SetProbeInterval(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.probeEvery = d
+}
+
+// Pin sets the concrete version for an alias on an endpoint.
+func (p *VersionPinner) Pin(endpoint types.Endpoint, alias, version types.ModelVersion) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.setLocked(endpoint, normalizeAlias(alias), version)
+}
+
+// Unpin removes the pin for an alias on an endpoint.
+func (p *VersionPinner) Unpin(endpoint types.Endpoint, alias types.ModelVersion) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.pins[endpoint], normalizeAlias(alias))
+}
+
+// Pinned returns the pinned version for an alias on an endpoint.
+func (p *VersionPinner) Pinned(endpoint types.Endpoint, alias types.ModelVersion) (types.ModelVersion, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	v, ok := p.pins[endpoint][normalizeAlias(alias)]
+	return v, ok
+}
+
+// Resolve returns the version to send for a request. In PinEnforce mode
+// pinned aliases are replaced, except for probes; otherwise requested is
+// returned as is.
+func (p *VersionPinner) Resolve(endpoint types.Endpoint, requested types.ModelVersion) types.ModelVersion {
+	if p.mode != PinEnforce {
+		return requested
+	}
+	alias := normalizeAlias(requested)
+	if !alias.IsAlias() {
+		return requested
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	v, ok := p.pins[endpoint][alias]
+	if !ok {
+		return requested
+	}
+	if p.probeEvery > 0 {
+		now := time.Now()
+		if last := p.probed[endpoint][alias]; now.Sub(last) >= p.probeEvery {
+			if p.probed[endpoint] == nil {
+				p.probed[endpoint] = make(map[types.ModelVersion]time.Time)
+			}
+			p.probed[endpoint][alias] = now
+			return requested
+		}
+	}
+	return v
+}
+
+// Observe records the version the server used for a request that was
+// sent with version sent. The first resolution of an alias is pinned;
+// later resolutions that differ are reported as drift.
+func (p *VersionPinner) Observe(endpoint types.Endpoint, sent types.ModelVersion, served string) (DriftEvent, bool) {
+	alias := normalizeAlias(sent)
+	if served == "" || !alias.IsAlias() {
+		return DriftEvent{}, false
+	}
+
+	p.mu.Lock()
+	pinned, ok := p.pins[endpoint][alias]
+	if !ok {
+		p.setLocked(endpoint, alias, types.ModelVersion(served))
+		p.mu.Unlock()
+		return DriftEvent{}, false
+	}
+	p.mu.Unlock()
+
+	if pinned == types.ModelVersion(served) {
+		return DriftEvent{}, false
+	}
+
+	event := DriftEvent{
+		Endpoint: endpoint,
+		Alias:    alias,
+		Pinned:   pinned,
+		Served:   types.ModelVersion(served),
+	}
+	if p.onDrift != nil {
+		p.onDrift(event)
+	}
+	return event, true
+}
+
+// lockFile is the on-disk lock file format.
+type lockFile struct {
+	Pins map[types.Endpoint]map[types.ModelVersion]types.ModelVersion `json:"pins"`
+}
+
+// Export writes the pins as a JSON lock file.
+func (p *VersionPinner) Export(w io.Writer) error {
+	p.mu.Lock()
+	lf := lockFile{Pins: make(map[types.Endpoint]map[types.ModelVersion]types.ModelVersion, len(p.pins))}
+	for endpoint, pins := range p.pins {
+		if len(pins) == 0 {
+			continue
+		}
+		m := make(map[types.ModelVersion]types.ModelVersion, len(pins))
+		for alias, v := range pins {
+			m[alias] = v
+		}
+		lf.Pins[endpoint] = m
+	}
+	p.mu.Unlock()
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(lf)
+}
+
+// Import replaces the pins with those read from a JSON lock file.
+func (p *VersionPinner) Import(r io.Reader) error {
+	var lf lockFile
+	if err := json.NewDecoder(r).Decode(&lf); err != nil {
+		return fmt.Errorf("read lock file: %w", err)
+	}
+
+	pins := make(map[types.Endpoint]map[types.ModelVersion]types.ModelVersion, len(lf.Pins))
+	for endpoint, m := range lf.Pins {
+		for alias, v := range m {
+			if !alias.IsAlias() {
+				return fmt.Errorf("read lock file: %q is not an alias", alias)
+			}
+			if !v.ValidFor(endpoint) {
+				return fmt.Errorf("read lock file: %s cannot be used with %s", v, endpoint.Path())
+			}
+		}
+		pins[endpoint] = m
+	}
+
+	p.mu.Lock()
+	p.pins = pins
+	p.mu.Unlock()
+	return nil
+}
+
+// SaveFile writes the lock file to path.
+func (p *VersionPinner) SaveFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := p.Export(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadFile reads the lock file at path.
+func (p *VersionPinner) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return p.Import(f)
+}
+
+// Pins returns a sorted, human readable list of the current pins.
+func (p *VersionPinner) Pins() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var out []string
+	for endpoint, pins := range p.pins {
+		for alias, v := range pins {
+			out = append(out, fmt.Sprintf("%s %s=%s", endpoint, alias, v))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (p *VersionPinner) setLocked(endpoint types.Endpoint, alias, version types.ModelVersion) {
+	if p.pins[endpoint] == nil {
+		p.pins[endpoint] = make(map[types.ModelVersion]types.ModelVersion)
+	}
+	p.pins[endpoint][alias] = version
+}
+
+// normalizeAlias treats an empty version as "latest", the API default.
+func normalizeAlias(v types.ModelVersion) types.ModelVersion {
+	if v == "" {
+		return types.VersionLatest
+	}
+	return v
+}
+
+// resolveVersion applies the service's pinner, if any.
+func (s *Service) resolveVersion(endpoint types.Endpoint, requested types.ModelVersion) types.ModelVersion {
+	if s.pinner == nil {
+		return requested
+	}
+	return s.pinner.Resolve(endpoint, requested)
+}
+
+// observeVersion reports the served version to the service's pinner
+// and records it if the API marked it deprecated.
+func (s *Service) observeVersion(endpoint types.Endpoint, sent types.ModelVersion, served string, deprecated bool) {
+	if deprecated && served != "" && s.markDeprecated(types.ModelVersion(served)) {
+		s.transport.Logf("model version %s is deprecated", served)
+	}
+	if s.pinner == nil {
+		return
+	}
+	if event, drift := s.pinner.Observe(endpoint, sent, served); drift {
+		s.transport.Logf("version drift: %s", event)
+	}
+}
@@ -3,6 +3,8 @@ package image
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/shamspias/reve-go/internal/transport"
@@ -41,25 +43,58 @@ type RemixParams struct {
 }
 
 // Validate validates the parameters.
+// All problems are reported together in a *types.ValidationError.
 func (p *RemixParams) Validate() error {
-	if err := validator.ValidatePrompt(p.Prompt); err != nil {
-		return err
+	var errs validator.Errors
+	errs.Check("prompt", validator.ValidatePrompt(p.Prompt))
+	errs.Check("reference_images", validator.ValidateReferenceImages(p.ReferenceImages))
+	for i, img := range p.ReferenceImages {
+		errs.Check(fmt.Sprintf("reference_images[%d]", i), validator.ValidateReferenceImage(img))
 	}
-	if err := validator.ValidateReferenceImages(p.ReferenceImages); err != nil {
-		return err
+	seen := make(map[int]bool)
+	for _, ref := range types.ParseRefs(p.Prompt) {
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		errs.Check(fmt.Sprintf("reference_images[%d]", ref), validator.ValidateImageRefs([]int{ref}, len(p.ReferenceImages)))
 	}
-	if err := validator.ValidateAspectRatio(string(p.AspectRatio)); err != nil {
-		return err
+	errs.Check("aspect_ratio", validator.ValidateAspectRatio(string(p.AspectRatio)))
+	errs.Check("version", validator.ValidateVersion(p.Version, types.EndpointRemix))
+	errs.Check("test_time_scaling", validator.ValidateScaling(p.TestTimeScaling))
+	validatePostprocess(&errs, p.Postprocess)
+	return errs.Err()
+}
+
+// UnreferencedImages returns the indices of reference images that the
+// prompt never mentions. A prompt without any <img>N</img> tags lets the
+// model use all images implicitly, so nil is returned in that case.
+func (p *RemixParams) UnreferencedImages() []int {
+	refs := types.ParseRefs(p.Prompt)
+	if len(refs) == 0 {
+		return nil
 	}
-	if err := validator.ValidateScaling(p.TestTimeScaling); err != nil {
-		return err
+	used := make(map[int]bool, len(refs))
+	for _, ref := range refs {
+		used[ref] = true
 	}
-	for _, pp := range p.Postprocess {
-		if err := pp.Validate(); err != nil {
-			return err
+	var out []int
+	for i := range p.ReferenceImages {
+		if !used[i] {
+			out = append(out, i)
 		}
 	}
-	return nil
+	return out
+}
+
+// Warnings returns non-fatal problems with the parameters, such as
+// reference images the prompt never mentions.
+func (p *RemixParams) Warnings() []string {
+	var out []string
+	for _, idx := range p.UnreferencedImages() {
+		out = append(out, fmt.Sprintf("reference image %d is not mentioned in the prompt (use %s)", idx, types.Ref(idx)))
+	}
+	return out
 }
 
 // Remix combines multiple images with a text prompt.
@@ -73,20 +108,27 @@ func (p *RemixParams) Validate() error {
 //		Prompt: fmt.Sprintf("Apply style from %s to %s", types.Ref(0), types.Ref(1)),
 //		ReferenceImages: []string{style.Base64(), content.Base64()},
 //	})
-func (s *Service) Remix(ctx context.Context, params *RemixParams) (*types.Result, error) {
+func (s *Service) Remix(ctx context.Context, params *RemixParams, opts ...RequestOption) (*types.Result, error) {
 	if params == nil {
-		return nil, validator.ErrEmptyPrompt
+		return nil, (&RemixParams{}).Validate()
 	}
 	if err := params.Validate(); err != nil {
 		return nil, err
 	}
+	for _, w := range params.Warnings() {
+		s.transport.Logf("warning: %s", w)
+	}
 
-	resp, err := s.transport.Do(ctx, &transport.Request{
+	body := *params
+	body.Version = s.resolveVersion(types.EndpointRemix, params.Version)
+
+	req := newRequest(&transport.Request{
 		Method:     http.MethodPost,
 		Path:       "/v1/image/remix",
-		Body:       params,
+		Body:       &body,
 		Breadcrumb: params.Breadcrumb,
-	})
+	}, opts)
+	resp, err := s.transport.Do(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -95,8 +137,12 @@ func (s *Service) Remix(ctx context.Context, params *RemixParams) (*types.Result
 	if err := json.Unmarshal(resp.Body, &result); err != nil {
 		return nil, err
 	}
+	result.KeyLabel = resp.KeyLabel
+	result.BaseURL = resp.BaseURL
+	result.Deprecated = resp.Deprecated
+	s.observeVersion(types.EndpointRemix, body.Version, result.Version, resp.Deprecated)
 
-	return &result, nil
+	return &result, s.store(ctx, types.EndpointRemix, params.Prompt, req.Breadcrumb, &result)
 }
 
 // RemixRaw combines images and returns raw bytes.
@@ -109,30 +155,19 @@ func (s *Service) Remix(ctx context.Context, params *RemixParams) (*types.Result
 //		ReferenceImages: images,
 //		Version:         types.VersionLatestFast,
 //	}, types.FormatWebP)
-func (s *Service) RemixRaw(ctx context.Context, params *RemixParams, format types.OutputFormat) (*types.RawResult, error) {
-	if params == nil {
-		return nil, validator.ErrEmptyPrompt
-	}
-	if err := params.Validate(); err != nil {
+func (s *Service) RemixRaw(ctx context.Context, params *RemixParams, format types.OutputFormat, opts ...RequestOption) (*types.RawResult, error) {
+	req, body, err := s.remixRawRequest(params, format, opts)
+	if err != nil {
 		return nil, err
 	}
-
-	if format == "" || format == types.FormatJSON {
-		format = types.FormatPNG
-	}
-
-	resp, err := s.transport.DoRaw(ctx, &transport.Request{
-		Method:     http.MethodPost,
-		Path:       "/v1/image/remix",
-		Body:       params,
-		Accept:     string(format),
-		Breadcrumb: params.Breadcrumb,
-	})
+	resp, err := s.transport.DoRaw(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
-	return &types.RawResult{
+	s.observeVersion(types.EndpointRemix, body.Version, resp.Version, resp.Deprecated)
+
+	raw := &types.RawResult{
 		Data:             resp.Data,
 		ContentType:      resp.ContentType,
 		Version:          resp.Version,
@@ -140,5 +175,60 @@ func (s *Service) RemixRaw(ctx context.Context, params *RemixParams, format type
 		RequestID:        resp.RequestID,
 		CreditsUsed:      resp.CreditsUsed,
 		CreditsRemaining: resp.CreditsRemaining,
-	}, nil
+		KeyLabel:         resp.KeyLabel,
+		BaseURL:          resp.BaseURL,
+		Deprecated:       resp.Deprecated,
+	}
+	return raw, s.storeRaw(ctx, types.EndpointRemix, params.Prompt, req.Breadcrumb, raw)
+}
+
+// RemixTo combines images and copies the raw bytes into w as they arrive,
+// without holding the image in memory. The result carries the metadata
+// from the response headers. Results are not stored in the client's sink.
+// Nothing is written to w when the request fails with an error status.
+//
+// Example:
+//
+//	f, _ := os.Create("blend.webp")
+//	defer f.Close()
+//	result, err := client.Images.RemixTo(ctx, &image.RemixParams{
+//		Prompt:          "Blend these styles",
+//		ReferenceImages: images,
+//	}, types.FormatWebP, f)
+func (s *Service) RemixTo(ctx context.Context, params *RemixParams, format types.OutputFormat, w io.Writer, opts ...RequestOption) (*types.StreamResult, error) {
+	req, body, err := s.remixRawRequest(params, format, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.stream(ctx, types.EndpointRemix, body.Version, req, w)
+}
+
+// remixRawRequest validates params and builds the request for a raw
+// response in format.
+func (s *Service) remixRawRequest(params *RemixParams, format types.OutputFormat, opts []RequestOption) (*transport.Request, *RemixParams, error) {
+	if params == nil {
+		return nil, nil, (&RemixParams{}).Validate()
+	}
+	if err := params.Validate(); err != nil {
+		return nil, nil, err
+	}
+	for _, w := range params.Warnings() {
+		s.transport.Logf("warning: %s", w)
+	}
+
+	if format == "" || format == types.FormatJSON {
+		format = types.FormatPNG
+	}
+
+	body := *params
+	body.Version = s.resolveVersion(types.EndpointRemix, params.Version)
+
+	req := newRequest(&transport.Request{
+		Method:     http.MethodPost,
+		Path:       "/v1/image/remix",
+		Body:       &body,
+		Accept:     string(format),
+		Breadcrumb: params.Breadcrumb,
+	}, opts)
+	return req, &body, nil
 }
@@ -0,0 +1,142 @@
+package image
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/shamspias/reve-go/internal/transport"
+)
+
+// RequestConfig holds the settings a RequestOption can change for a
+// single call. Zero values keep the client defaults.
+type RequestConfig struct {
+	// Timeout limits each attempt of the request.
+	Timeout time.Duration
+
+	// Retry replaces the client's retry policy when non-nil.
+	Retry *RetryPolicy
+
+	// Header holds extra HTTP headers. They replace any header of the
+	// same name set by the client.
+	Header http.Header
+
+	// IdempotencyKey is sent in the Idempotency-Key header on every
+	// attempt.
+	IdempotencyKey string
+
+	// Breadcrumb replaces the Breadcrumb field of the params.
+	Breadcrumb string
+
+	// Accept replaces the Accept header.
+	Accept string
+
+	// MaxResponseSize replaces the client's response size limit.
+	MaxResponseSize int64
+}
+
+// RetryPolicy configures retries for a single call.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries. Zero disables retries.
+	MaxRetries int
+
+	// MinWait and MaxWait bound the backoff between attempts.
+	MinWait time.Duration
+	MaxWait time.Duration
+}
+
+// RequestOption overrides client defaults for a single call.
+//
+// Example:
+//
+//	result, err := client.Images.Create(ctx, params,
+//		image.WithRequestTimeout(30*time.Second),
+//		image.WithIdempotencyKey(orderID),
+//	)
+type RequestOption func(*RequestConfig)
+
+// WithRequestTimeout sets the timeout for each attempt of this request.
+func WithRequestTimeout(d time.Duration) RequestOption {
+	return func(c *RequestConfig) {
+		c.Timeout = d
+	}
+}
+
+// WithRequestRetry sets the retry behavior for this request.
+// A maxRetries of 0 disables retries.
+func WithRequestRetry(maxRetries int, minWait, maxWait time.Duration) RequestOption {
+	return func(c *RequestConfig) {
+		c.Retry = &RetryPolicy{MaxRetries: maxRetries, MinWait: minWait, MaxWait: maxWait}
+	}
+}
+
+// WithHeader sets an extra HTTP header on this request. It replaces any
+// header of the same name set by the client.
+func WithHeader(key, value string) RequestOption {
+	return func(c *RequestConfig) {
+		if c.Header == nil {
+			c.Header = make(http.Header)
+		}
+		c.Header.Set(key, value)
+	}
+}
+
+// WithIdempotencyKey sends key in the Idempotency-Key header. The same
+// key is sent on every retry.
+func WithIdempotencyKey(key string) RequestOption {
+	return func(c *RequestConfig) {
+		c.IdempotencyKey = key
+	}
+}
+
+// WithBreadcrumb sets the tracking ID for this request, replacing the
+// Breadcrumb field of the params.
+func WithBreadcrumb(breadcrumb string) RequestOption {
+	return func(c *RequestConfig) {
+		c.Breadcrumb = breadcrumb
+	}
+}
+
+// WithAccept sets the Accept header. With the Raw methods it replaces
+// the format argument; the JSON methods expect application/json.
+func WithAccept(mime string) RequestOption {
+	return func(c *RequestConfig) {
+		c.Accept = mime
+	}
+}
+
+// WithRequestMaxResponseSize limits the response body of this request to n
+// bytes, replacing the client's limit. A larger response fails with
+// transport.ErrResponseTooLarge.
+func WithRequestMaxResponseSize(n int64) RequestOption {
+	return func(c *RequestConfig) {
+		c.MaxResponseSize = n
+	}
+}
+
+// NewRequestConfig returns the settings produced by opts.
+func NewRequestConfig(opts ...RequestOption) *RequestConfig {
+	c := &RequestConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// newRequest applies opts to req.
+func newRequest(req *transport.Request, opts []RequestOption) *transport.Request {
+	c := NewRequestConfig(opts...)
+	if c.Accept != "" {
+		req.Accept = c.Accept
+	}
+	if c.Breadcrumb != "" {
+		req.Breadcrumb = c.Breadcrumb
+	}
+	if c.Retry != nil {
+		req.Retrier = transport.NewRetrier(c.Retry.MaxRetries, c.Retry.MinWait, c.Retry.MaxWait)
+	}
+	req.Timeout = c.Timeout
+	req.Header = c.Header
+	req.IdempotencyKey = c.IdempotencyKey
+	req.MaxResponseSize = c.MaxResponseSize
+	return req
+}
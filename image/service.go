@@ -5,6 +5,7 @@
 //   - Edit: Modify images with text instructions
 //   - Remix: Combine images with text prompts
 //   - Batch operations for concurrent processing
+//   - Async jobs on a bounded worker pool
 //
 // # Usage
 //
@@ -21,15 +22,61 @@
 package image
 
 import (
+	"fmt"
+	"sync"
+
 	"github.com/shamspias/reve-go/internal/transport"
+	"github.com/shamspias/reve-go/internal/validator"
+	"github.com/shamspias/reve-go/output"
+	"github.com/shamspias/reve-go/types"
 )
 
 // Service handles image operations.
 type Service struct {
 	transport *transport.Client
+	pinner    *VersionPinner
+	pool      *workerPool
+
+	sink         output.Sink
+	sinkTemplate output.Template
+
+	mu         sync.Mutex
+	deprecated map[types.ModelVersion]bool
+}
+
+// validatePostprocess records errors for each postprocessing operation,
+// under the path of the field at fault.
+func validatePostprocess(errs *validator.Errors, list []types.Postprocess) {
+	for i, pp := range list {
+		errs.Check(postprocessPath(i, pp), pp.Validate())
+	}
+}
+
+// postprocessPath returns the path errors in the i-th postprocessing
+// operation are reported under.
+func postprocessPath(i int, pp types.Postprocess) string {
+	if pp.Process == types.ProcessUpscale {
+		return fmt.Sprintf("postprocessing[%d].upscale_factor", i)
+	}
+	return fmt.Sprintf("postprocessing[%d].process", i)
+}
+
+// ServiceOption configures a Service.
+type ServiceOption func(*Service)
+
+// WithVersionPinner records and optionally pins the model versions
+// resolved from aliases.
+func WithVersionPinner(p *VersionPinner) ServiceOption {
+	return func(s *Service) {
+		s.pinner = p
+	}
 }
 
 // NewService creates a new image service.
-func NewService(t *transport.Client) *Service {
-	return &Service{transport: t}
+func NewService(t *transport.Client, opts ...ServiceOption) *Service {
+	s := &Service{transport: t, pool: newWorkerPool()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
@@ -0,0 +1,56 @@
+package image
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shamspias/reve-go/output"
+	"github.com/shamspias/reve-go/types"
+)
+
+// WithSink stores every successful result in sink under a key produced
+// by tmpl. Results flagged for content violations are not stored.
+//
+// If storing fails, the method returns the result together with the
+// error so the image is not lost.
+func WithSink(sink output.Sink, tmpl output.Template) ServiceOption {
+	return func(s *Service) {
+		s.sink = sink
+		s.sinkTemplate = tmpl
+	}
+}
+
+func (s *Service) store(ctx context.Context, endpoint types.Endpoint, prompt, breadcrumb string, result *types.Result) error {
+	if s.sink == nil || result.ContentViolation {
+		return nil
+	}
+	meta := output.Meta{Endpoint: endpoint, Prompt: prompt, Breadcrumb: breadcrumb}
+	if _, err := output.PutResult(ctx, s.sink, s.sinkTemplate, result, meta); err != nil {
+		return fmt.Errorf("image: storing result: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) storeRaw(ctx context.Context, endpoint types.Endpoint, prompt, breadcrumb string, result *types.RawResult) error {
+	if s.sink == nil || result.ContentViolation {
+		return nil
+	}
+	meta := output.Meta{Endpoint: endpoint, Prompt: prompt, Breadcrumb: breadcrumb}
+	if _, err := output.PutRaw(ctx, s.sink, s.sinkTemplate, result, meta); err != nil {
+		return fmt.Errorf("image: storing result: %w", err)
+	}
+	return nil
+}
+
+// store saves a batch result in the config's sink and returns its key.
+func (c *BatchConfig) store(ctx context.Context, endpoint types.Endpoint, index int, prompt, breadcrumb string, result *types.Result) (string, error) {
+	if c.Sink == nil || result == nil || result.ContentViolation {
+		return "", nil
+	}
+	meta := output.Meta{Endpoint: endpoint, Index: index, Prompt: prompt, Breadcrumb: breadcrumb}
+	key, err := output.PutResult(ctx, c.Sink, c.Naming, result, meta)
+	if err != nil {
+		return "", fmt.Errorf("image: storing result: %w", err)
+	}
+	return key, nil
+}
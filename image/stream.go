@@ -0,0 +1,32 @@
+package image
+
+import (
+	"context"
+	"io"
+
+	"github.com/shamspias/reve-go/internal/transport"
+	"github.com/shamspias/reve-go/types"
+)
+
+// stream sends a raw request and copies the image into w.
+func (s *Service) stream(ctx context.Context, endpoint types.Endpoint, sent types.ModelVersion, req *transport.Request, w io.Writer) (*types.StreamResult, error) {
+	resp, err := s.transport.DoStream(ctx, req, w)
+	if err != nil {
+		return nil, err
+	}
+
+	s.observeVersion(endpoint, sent, resp.Version, resp.Deprecated)
+
+	return &types.StreamResult{
+		ContentType:      resp.ContentType,
+		Version:          resp.Version,
+		ContentViolation: resp.ContentViolation,
+		RequestID:        resp.RequestID,
+		CreditsUsed:      resp.CreditsUsed,
+		CreditsRemaining: resp.CreditsRemaining,
+		KeyLabel:         resp.KeyLabel,
+		BaseURL:          resp.BaseURL,
+		Deprecated:       resp.Deprecated,
+		Size:             resp.Size,
+	}, nil
+}
@@ -0,0 +1,147 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultBaseURLCooldown is how long a failing base URL is skipped
+// before the client fails back to it.
+const DefaultBaseURLCooldown = 30 * time.Second
+
+// BaseURLStats reports the state of one base URL.
+type BaseURLStats struct {
+	URL      string
+	Requests int
+	Failures int
+
+	// CoolingUntil is when the URL is preferred again after a failure.
+	// It is zero for healthy URLs.
+	CoolingUntil time.Time
+
+	// LastError is the last error that caused a failover, or nil.
+	LastError error
+}
+
+// baseURLs tracks the health of an ordered list of base URLs. Requests
+// go to the first healthy URL; a URL that fails with a connection error
+// or a 5xx is skipped until its cooldown ends.
+type baseURLs struct {
+	cooldown time.Duration
+
+	mu    sync.Mutex
+	slots []*baseURLSlot
+}
+
+type baseURLSlot struct {
+	url       string
+	requests  int
+	failures  int
+	coolUntil time.Time
+	lastError error
+}
+
+func newBaseURLs(urls []string, cooldown time.Duration) *baseURLs {
+	if cooldown <= 0 {
+		cooldown = DefaultBaseURLCooldown
+	}
+	b := &baseURLs{cooldown: cooldown}
+	for _, u := range urls {
+		b.slots = append(b.slots, &baseURLSlot{url: u})
+	}
+	return b
+}
+
+// order returns the URLs to try: healthy ones in preference order, then
+// cooling ones by when they recover.
+func (b *baseURLs) order(now time.Time) []*baseURLSlot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var healthy, cooling []*baseURLSlot
+	for _, s := range b.slots {
+		if s.coolUntil.After(now) {
+			cooling = append(cooling, s)
+		} else {
+			healthy = append(healthy, s)
+		}
+	}
+	for i := 1; i < len(cooling); i++ {
+		for j := i; j > 0 && cooling[j].coolUntil.Before(cooling[j-1].coolUntil); j-- {
+			cooling[j], cooling[j-1] = cooling[j-1], cooling[j]
+		}
+	}
+	return append(healthy, cooling...)
+}
+
+// report records the outcome of a request to s.
+func (b *baseURLs) report(s *baseURLSlot, err error, failover bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s.requests++
+	switch {
+	case err == nil:
+		s.coolUntil = time.Time{}
+	case failover:
+		s.failures++
+		s.coolUntil = time.Now().Add(b.cooldown)
+		s.lastError = err
+	}
+}
+
+func (b *baseURLs) stats() []BaseURLStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	stats := make([]BaseURLStats, len(b.slots))
+	for i, s := range b.slots {
+		stats[i] = BaseURLStats{
+			URL:       s.url,
+			Requests:  s.requests,
+			Failures:  s.failures,
+			LastError: s.lastError,
+		}
+		if s.coolUntil.After(now) {
+			stats[i].CoolingUntil = s.coolUntil
+		}
+	}
+	return stats
+}
+
+// shouldFailover reports whether err means the base URL is unavailable:
+// a connection error or a 5xx response.
+func shouldFailover(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+	var reqErr *RequestError
+	return errors.As(err, &reqErr) && reqErr.Op == "http"
+}
+
+// eachBaseURL calls fn with each base URL in turn until it succeeds or
+// fails with an error that is not a reason to fail over.
+func (c *Client) eachBaseURL(ctx context.Context, fn func(baseURL string) error) error {
+	var err error
+	for i, s := range c.baseURLs.order(time.Now()) {
+		if i > 0 {
+			c.log("Failing over to %s after: %v", s.url, err)
+		}
+		err = fn(s.url)
+		failover := shouldFailover(ctx, err)
+		c.baseURLs.report(s, err, failover)
+		if !failover {
+			return err
+		}
+	}
+	return err
+}
+
+// BaseURLStats returns the state of every base URL, in preference order.
+func (c *Client) BaseURLStats() []BaseURLStats {
+	return c.baseURLs.stats()
+}
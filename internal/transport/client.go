@@ -4,27 +4,24 @@ package transport
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"net/url"
 	"time"
-
-	"golang.org/x/net/proxy"
 )
 
 // Client handles HTTP communication with the Reve API.
 type Client struct {
-	httpClient *http.Client
-	baseURL    string
-	apiKey     string
-	userAgent  string
-	debug      bool
-	logger     Logger
-	retrier    *Retrier
+	httpClient  *http.Client
+	baseURLs    *baseURLs
+	credentials CredentialsProvider
+	userAgent   string
+	debug       bool
+	logger      Logger
+	retrier     *Retrier
+	maxResponse int64
 }
 
 // Logger is a function type for logging.
@@ -42,6 +39,22 @@ type Config struct {
 	Debug        bool
 	Logger       Logger
 	Transport    http.RoundTripper
+
+	// Credentials supplies the API key per request. If nil, APIKey is
+	// used.
+	Credentials CredentialsProvider
+
+	// BaseURLs is an ordered list of base URLs to fail over between. If
+	// empty, BaseURL is used.
+	BaseURLs []string
+
+	// BaseURLCooldown is how long a failing base URL is skipped.
+	// Default: 30 seconds
+	BaseURLCooldown time.Duration
+
+	// MaxResponseSize limits the size of a response body in bytes.
+	// Zero means no limit.
+	MaxResponseSize int64
 }
 
 // New creates a new transport client.
@@ -54,14 +67,25 @@ func New(cfg *Config) *Client {
 		httpClient.Transport = cfg.Transport
 	}
 
+	credentials := cfg.Credentials
+	if credentials == nil {
+		credentials = StaticCredentials(cfg.APIKey)
+	}
+
+	urls := cfg.BaseURLs
+	if len(urls) == 0 {
+		urls = []string{cfg.BaseURL}
+	}
+
 	return &Client{
-		httpClient: httpClient,
-		baseURL:    cfg.BaseURL,
-		apiKey:     cfg.APIKey,
-		userAgent:  cfg.UserAgent,
-		debug:      cfg.Debug,
-		logger:     cfg.Logger,
-		retrier:    NewRetrier(cfg.MaxRetries, cfg.RetryMinWait, cfg.RetryMaxWait),
+		httpClient:  httpClient,
+		baseURLs:    newBaseURLs(urls, cfg.BaseURLCooldown),
+		credentials: credentials,
+		userAgent:   cfg.UserAgent,
+		debug:       cfg.Debug,
+		logger:      cfg.Logger,
+		retrier:     NewRetrier(cfg.MaxRetries, cfg.RetryMinWait, cfg.RetryMaxWait),
+		maxResponse: cfg.MaxResponseSize,
 	}
 }
 
@@ -72,6 +96,14 @@ type Request struct {
 	Body       any
 	Accept     string
 	Breadcrumb string
+
+	// Per-request overrides. Zero values fall back to the client
+	// configuration.
+	Timeout         time.Duration
+	Retrier         *Retrier
+	Header          http.Header
+	IdempotencyKey  string
+	MaxResponseSize int64
 }
 
 // Response represents a JSON response.
@@ -79,6 +111,11 @@ type Response struct {
 	Body      []byte
 	Status    int
 	RequestID string
+	KeyLabel  string
+	BaseURL   string
+
+	// Deprecated is true if the response has a Deprecation header.
+	Deprecated bool
 }
 
 // RawResponse represents a binary response.
@@ -90,37 +127,171 @@ type RawResponse struct {
 	RequestID        string
 	CreditsUsed      int
 	CreditsRemaining int
+	KeyLabel         string
+	BaseURL          string
+
+	// Deprecated is true if the response has a Deprecation header.
+	Deprecated bool
+
+	// Size is the number of image bytes read, including those written
+	// to the writer passed to DoStream.
+	Size int64
 }
 
 // Do executes a request and returns JSON response.
 func (c *Client) Do(ctx context.Context, req *Request) (*Response, error) {
-	return c.retrier.Do(ctx, func() (*Response, error) {
-		return c.execute(ctx, req)
+	refreshed, switched := false, false
+	return c.retrierFor(req).Do(ctx, func() (resp *Response, err error) {
+		err = c.eachBaseURL(ctx, func(baseURL string) error {
+			resp, err = c.execute(ctx, req, baseURL)
+			if c.refreshOnAuthError(ctx, err, &refreshed) || c.switchKey(err, &switched) {
+				resp, err = c.execute(ctx, req, baseURL)
+			}
+			return err
+		})
+		return resp, err
 	})
 }
 
 // DoRaw executes a request and returns raw binary response.
 func (c *Client) DoRaw(ctx context.Context, req *Request) (*RawResponse, error) {
-	return c.retrier.DoRaw(ctx, func() (*RawResponse, error) {
-		return c.executeRaw(ctx, req)
+	return c.doRaw(ctx, req, nil)
+}
+
+// DoStream executes a request and copies the binary response into w
+// instead of holding it in memory. The returned RawResponse has no Data.
+// Failed attempts are retried only until the first byte is written to
+// w.
+func (c *Client) DoStream(ctx context.Context, req *Request, w io.Writer) (*RawResponse, error) {
+	return c.doRaw(ctx, req, w)
+}
+
+func (c *Client) doRaw(ctx context.Context, req *Request, w io.Writer) (*RawResponse, error) {
+	refreshed, switched := false, false
+	return c.retrierFor(req).DoRaw(ctx, func() (resp *RawResponse, err error) {
+		err = c.eachBaseURL(ctx, func(baseURL string) error {
+			resp, err = c.executeRaw(ctx, req, baseURL, w)
+			if c.refreshOnAuthError(ctx, err, &refreshed) || c.switchKey(err, &switched) {
+				resp, err = c.executeRaw(ctx, req, baseURL, w)
+			}
+			return err
+		})
+		return resp, err
 	})
 }
 
-func (c *Client) execute(ctx context.Context, req *Request) (*Response, error) {
-	httpReq, err := c.buildRequest(ctx, req)
+// refreshOnAuthError refreshes the credentials after an INVALID_API_KEY
+// error and reports whether the request should be sent again. It
+// refreshes at most once per request.
+func (c *Client) refreshOnAuthError(ctx context.Context, err error, refreshed *bool) bool {
+	apiErr, ok := err.(*APIError)
+	if !ok || !apiErr.IsAuthError() || *refreshed {
+		return false
+	}
+	r, ok := c.credentials.(CredentialsRefresher)
+	if !ok {
+		return false
+	}
+	*refreshed = true
+	if rerr := r.Refresh(ctx); rerr != nil {
+		c.log("Credentials refresh failed: %v", rerr)
+		return false
+	}
+	c.log("Credentials refreshed after %s", apiErr.Code)
+	return true
+}
+
+// switchKey reports whether a request that failed with
+// INSUFFICIENT_CREDITS or a rate limit should be sent again at once on
+// another key from the pool. The failed key is already cooling down, so
+// this is only done if another key is in rotation, and at most once per
+// request.
+func (c *Client) switchKey(err error, switched *bool) bool {
+	apiErr, ok := err.(*APIError)
+	if !ok || *switched || !(apiErr.IsInsufficientFunds() || apiErr.IsRateLimit()) {
+		return false
+	}
+	pool, ok := c.credentials.(*KeyPool)
+	if !ok || !pool.inRotation(time.Now()) {
+		return false
+	}
+	*switched = true
+	c.log("Switching API key after %s", apiErr.Code)
+	return true
+}
+
+func (c *Client) retrierFor(req *Request) *Retrier {
+	if req.Retrier != nil {
+		return req.Retrier
+	}
+	return c.retrier
+}
+
+func (c *Client) httpClientFor(req *Request) *http.Client {
+	if req.Timeout <= 0 {
+		return c.httpClient
+	}
+	hc := *c.httpClient
+	hc.Timeout = req.Timeout
+	return &hc
+}
+
+// lease is the API key used for one attempt.
+type lease struct {
+	key   string
+	label string
+	pool  *KeyPool
+	slot  *poolSlot
+}
+
+func (c *Client) acquire(ctx context.Context) (*lease, error) {
+	if pool, ok := c.credentials.(*KeyPool); ok {
+		s, err := pool.acquire(time.Now())
+		if err != nil {
+			return nil, &RequestError{Op: "credentials", Err: err}
+		}
+		return &lease{key: s.Key, label: s.Label, pool: pool, slot: s}, nil
+	}
+	key, err := c.credentials.APIKey(ctx)
+	if err != nil {
+		return nil, &RequestError{Op: "credentials", Err: err}
+	}
+	return &lease{key: key}, nil
+}
+
+// release reports the outcome of the attempt to the key pool, if any.
+func (l *lease) release(header http.Header, body []byte, err error) {
+	if l.pool != nil {
+		l.pool.release(l.slot, header, body, err)
+	}
+}
+
+func (c *Client) execute(ctx context.Context, req *Request, baseURL string) (_ *Response, err error) {
+	l, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		header http.Header
+		body   []byte
+	)
+	defer func() { l.release(header, body, err) }()
+
+	httpReq, err := c.buildRequest(ctx, req, baseURL, l.key)
 	if err != nil {
 		return nil, err
 	}
 
 	c.log("Request: %s %s", httpReq.Method, httpReq.URL)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.httpClientFor(req).Do(httpReq)
 	if err != nil {
 		return nil, &RequestError{Op: "http", Err: err}
 	}
 	defer resp.Body.Close()
+	header = resp.Header
 
-	body, err := io.ReadAll(resp.Body)
+	body, err = c.readBody(req, resp)
 	if err != nil {
 		return nil, &RequestError{Op: "read response", Err: err}
 	}
@@ -132,53 +303,89 @@ func (c *Client) execute(ctx context.Context, req *Request) (*Response, error) {
 	}
 
 	return &Response{
-		Body:      body,
-		Status:    resp.StatusCode,
-		RequestID: resp.Header.Get("X-Reve-Request-Id"),
+		Body:       body,
+		Status:     resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Reve-Request-Id"),
+		KeyLabel:   l.label,
+		BaseURL:    baseURL,
+		Deprecated: resp.Header.Get("Deprecation") != "",
 	}, nil
 }
 
-func (c *Client) executeRaw(ctx context.Context, req *Request) (*RawResponse, error) {
-	httpReq, err := c.buildRequest(ctx, req)
+// executeRaw sends one attempt. The body is copied into w, or buffered
+// in Data if w is nil.
+func (c *Client) executeRaw(ctx context.Context, req *Request, baseURL string, w io.Writer) (_ *RawResponse, err error) {
+	l, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var header http.Header
+	defer func() { l.release(header, nil, err) }()
+
+	httpReq, err := c.buildRequest(ctx, req, baseURL, l.key)
 	if err != nil {
 		return nil, err
 	}
 
 	c.log("Request (raw): %s %s", httpReq.Method, httpReq.URL)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.httpClientFor(req).Do(httpReq)
 	if err != nil {
 		return nil, &RequestError{Op: "http", Err: err}
 	}
 	defer resp.Body.Close()
+	header = resp.Header
 
-	if errCode := resp.Header.Get("X-Reve-Error-Code"); errCode != "" {
-		body, _ := io.ReadAll(resp.Body)
+	// Check for errors before anything is copied to w, so an error page
+	// from the API or a proxy in front of it is never mistaken for an
+	// image.
+	if resp.StatusCode >= 400 || resp.Header.Get("X-Reve-Error-Code") != "" {
+		body, _ := c.readBody(req, resp)
 		return nil, ParseError(resp, body)
 	}
 
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, &RequestError{Op: "read response", Err: err}
+	var (
+		data []byte
+		size int64
+	)
+	if w == nil {
+		data, err = c.readBody(req, resp)
+		size = int64(len(data))
+		if err != nil {
+			return nil, &RequestError{Op: "read response", Err: err}
+		}
+	} else {
+		body, lerr := c.limitBody(req, resp)
+		if lerr != nil {
+			return nil, &RequestError{Op: "read response", Err: lerr}
+		}
+		size, err = io.Copy(w, body)
+		if err != nil {
+			return nil, &RequestError{Op: "stream response", Err: err}
+		}
 	}
 
-	c.log("Response (raw): status=%d, size=%d", resp.StatusCode, len(data))
+	c.log("Response (raw): status=%d, size=%d", resp.StatusCode, size)
 
 	return &RawResponse{
 		Data:             data,
+		Size:             size,
 		ContentType:      resp.Header.Get("Content-Type"),
 		Version:          resp.Header.Get("X-Reve-Version"),
 		ContentViolation: resp.Header.Get("X-Reve-Content-Violation") == "true",
 		RequestID:        resp.Header.Get("X-Reve-Request-Id"),
 		CreditsUsed:      parseIntHeader(resp, "X-Reve-Credits-Used"),
 		CreditsRemaining: parseIntHeader(resp, "X-Reve-Credits-Remaining"),
+		KeyLabel:         l.label,
+		BaseURL:          baseURL,
+		Deprecated:       resp.Header.Get("Deprecation") != "",
 	}, nil
 }
 
-func (c *Client) buildRequest(ctx context.Context, req *Request) (*http.Request, error) {
-	url := c.baseURL + req.Path
+func (c *Client) buildRequest(ctx context.Context, req *Request, baseURL, apiKey string) (*http.Request, error) {
+	endpoint := baseURL + req.Path
 	if req.Breadcrumb != "" {
-		url += "?breadcrumb=" + req.Breadcrumb
+		endpoint += "?breadcrumb=" + url.QueryEscape(req.Breadcrumb)
 	}
 
 	var bodyReader io.Reader
@@ -196,13 +403,13 @@ func (c *Client) buildRequest(ctx context.Context, req *Request) (*http.Request,
 		}
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, req.Method, url, bodyReader)
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, endpoint, bodyReader)
 	if err != nil {
 		return nil, &RequestError{Op: "create request", Err: err}
 	}
 
 	httpReq.GetBody = getBody
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	httpReq.Header.Set("Authorization", "Bearer "+apiKey)
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("User-Agent", c.userAgent)
 
@@ -212,9 +419,73 @@ func (c *Client) buildRequest(ctx context.Context, req *Request) (*http.Request,
 	}
 	httpReq.Header.Set("Accept", accept)
 
+	if req.IdempotencyKey != "" {
+		httpReq.Header.Set("Idempotency-Key", req.IdempotencyKey)
+	}
+	for key, values := range req.Header {
+		httpReq.Header.Del(key)
+		for _, v := range values {
+			httpReq.Header.Add(key, v)
+		}
+	}
+
 	return httpReq, nil
 }
 
+// readBody reads the response body, enforcing the size limit.
+func (c *Client) readBody(req *Request, resp *http.Response) ([]byte, error) {
+	body, err := c.limitBody(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(body)
+}
+
+// limitBody returns the response body, limited to the maximum response
+// size. A body whose Content-Length already exceeds the limit is
+// rejected before anything is read.
+func (c *Client) limitBody(req *Request, resp *http.Response) (io.Reader, error) {
+	limit := c.maxResponse
+	if req.MaxResponseSize > 0 {
+		limit = req.MaxResponseSize
+	}
+	if limit <= 0 {
+		return resp.Body, nil
+	}
+	if resp.ContentLength > limit {
+		return nil, fmt.Errorf("%w: %d bytes, limit %d", ErrResponseTooLarge, resp.ContentLength, limit)
+	}
+	return &limitedReader{r: resp.Body, limit: limit, remaining: limit}, nil
+}
+
+// limitedReader fails with ErrResponseTooLarge once more than limit
+// bytes have been read.
+type limitedReader struct {
+	r         io.Reader
+	limit     int64
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, fmt.Errorf("%w: limit %d bytes", ErrResponseTooLarge, l.limit)
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n + int(l.remaining), fmt.Errorf("%w: limit %d bytes", ErrResponseTooLarge, l.limit)
+	}
+	return n, err
+}
+
+// Logf writes a debug log line using the configured logger.
+func (c *Client) Logf(format string, args ...any) {
+	c.log(format, args...)
+}
+
 func (c *Client) log(format string, args ...any) {
 	if !c.debug {
 		return
@@ -235,64 +506,3 @@ func parseIntHeader(resp *http.Response, key string) int {
 	fmt.Sscanf(val, "%d", &n)
 	return n
 }
-
-// CreateHTTPProxyTransport creates a transport with HTTP proxy.
-func CreateHTTPProxyTransport(proxyURL string) (http.RoundTripper, error) {
-	parsed, err := url.Parse(proxyURL)
-	if err != nil {
-		return nil, err
-	}
-
-	return &http.Transport{
-		Proxy: http.ProxyURL(parsed),
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
-		TLSClientConfig:       &tls.Config{MinVersion: tls.VersionTLS12},
-		MaxIdleConns:          100,
-		IdleConnTimeout:       90 * time.Second,
-		TLSHandshakeTimeout:   10 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
-	}, nil
-}
-
-// CreateSOCKS5ProxyTransport creates a transport with SOCKS5 proxy.
-func CreateSOCKS5ProxyTransport(addr, username, password string) (http.RoundTripper, error) {
-	var auth *proxy.Auth
-	if username != "" {
-		auth = &proxy.Auth{User: username, Password: password}
-	}
-
-	dialer, err := proxy.SOCKS5("tcp", addr, auth, proxy.Direct)
-	if err != nil {
-		return nil, err
-	}
-
-	return &http.Transport{
-		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-			return dialer.Dial(network, addr)
-		},
-		TLSClientConfig:       &tls.Config{MinVersion: tls.VersionTLS12},
-		MaxIdleConns:          100,
-		IdleConnTimeout:       90 * time.Second,
-		TLSHandshakeTimeout:   10 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
-	}, nil
-}
-
-// CreateEnvProxyTransport creates a transport using environment proxy settings.
-func CreateEnvProxyTransport() http.RoundTripper {
-	return &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
-		TLSClientConfig:       &tls.Config{MinVersion: tls.VersionTLS12},
-		MaxIdleConns:          100,
-		IdleConnTimeout:       90 * time.Second,
-		TLSHandshakeTimeout:   10 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
-	}
-}
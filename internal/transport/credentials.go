@@ -0,0 +1,168 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoCredentials is returned when no provider has an API key.
+var ErrNoCredentials = errors.New("reve: no API key available")
+
+// CredentialsProvider supplies the API key. It is called for every
+// request, so implementations should be cheap and safe for concurrent
+// use.
+type CredentialsProvider interface {
+	APIKey(ctx context.Context) (string, error)
+}
+
+// CredentialsRefresher is implemented by providers that can reload their
+// key. When a request fails with INVALID_API_KEY, the transport calls
+// Refresh once and retries the request.
+type CredentialsRefresher interface {
+	Refresh(ctx context.Context) error
+}
+
+// StaticCredentials returns a provider for a fixed key.
+func StaticCredentials(key string) CredentialsProvider {
+	return staticCredentials(key)
+}
+
+type staticCredentials string
+
+func (s staticCredentials) APIKey(context.Context) (string, error) {
+	return string(s), nil
+}
+
+// EnvCredentials returns a provider that reads the key from the named
+// environment variable on every request. An empty name means
+// REVE_API_KEY.
+func EnvCredentials(name string) CredentialsProvider {
+	if name == "" {
+		name = "REVE_API_KEY"
+	}
+	return envCredentials(name)
+}
+
+type envCredentials string
+
+func (e envCredentials) APIKey(context.Context) (string, error) {
+	key := strings.TrimSpace(os.Getenv(string(e)))
+	if key == "" {
+		return "", fmt.Errorf("%w: %s is not set", ErrNoCredentials, string(e))
+	}
+	return key, nil
+}
+
+// FileCredentials reads the key from a file and reloads it when the
+// file's size or modification time changes. Surrounding whitespace is
+// trimmed.
+type FileCredentials struct {
+	path string
+
+	mu      sync.Mutex
+	key     string
+	size    int64
+	modTime time.Time
+}
+
+// NewFileCredentials returns a provider backed by the file at path.
+func NewFileCredentials(path string) *FileCredentials {
+	return &FileCredentials{path: path}
+}
+
+// APIKey returns the current key, reloading the file if it changed.
+func (f *FileCredentials) APIKey(context.Context) (string, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrNoCredentials, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.key != "" && info.Size() == f.size && info.ModTime().Equal(f.modTime) {
+		return f.key, nil
+	}
+	if err := f.load(); err != nil {
+		return "", err
+	}
+	return f.key, nil
+}
+
+// Refresh rereads the file.
+func (f *FileCredentials) Refresh(context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.load()
+}
+
+func (f *FileCredentials) load() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNoCredentials, err)
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNoCredentials, err)
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return fmt.Errorf("%w: %s is empty", ErrNoCredentials, f.path)
+	}
+	f.key, f.size, f.modTime = key, info.Size(), info.ModTime()
+	return nil
+}
+
+// ChainCredentials returns a provider that tries each provider in order
+// and uses the first non-empty key.
+func ChainCredentials(providers ...CredentialsProvider) CredentialsProvider {
+	return chainCredentials(providers)
+}
+
+type chainCredentials []CredentialsProvider
+
+func (c chainCredentials) APIKey(ctx context.Context) (string, error) {
+	var errs []error
+	for _, p := range c {
+		key, err := p.APIKey(ctx)
+		if err == nil && key != "" {
+			return key, nil
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return "", ErrNoCredentials
+	}
+	return "", errors.Join(errs...)
+}
+
+// Refresh refreshes every provider in the chain that supports it. It
+// fails only if none of them could be refreshed.
+func (c chainCredentials) Refresh(ctx context.Context) error {
+	var errs []error
+	refreshed := false
+	for _, p := range c {
+		r, ok := p.(CredentialsRefresher)
+		if !ok {
+			continue
+		}
+		if err := r.Refresh(ctx); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		refreshed = true
+	}
+	switch {
+	case refreshed:
+		return nil
+	case len(errs) == 0:
+		return errors.New("reve: credentials cannot be refreshed")
+	}
+	return errors.Join(errs...)
+}
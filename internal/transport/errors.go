@@ -2,6 +2,7 @@ package transport
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 )
@@ -64,6 +65,10 @@ func (e *APIError) IsAuthError() bool {
 	return e.Code == ErrCodeInvalidAPIKey || e.StatusCode == http.StatusUnauthorized
 }
 
+// ErrResponseTooLarge is returned when a response body exceeds the
+// maximum response size.
+var ErrResponseTooLarge = errors.New("reve: response too large")
+
 // RequestError represents a request-level error.
 type RequestError struct {
 	Op  string
@@ -0,0 +1,283 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Key pool defaults.
+const (
+	DefaultKeyCooldown          = time.Minute
+	DefaultExhaustedKeyCooldown = 15 * time.Minute
+)
+
+// ErrEmptyKeyPool is returned by a key pool with no keys.
+var ErrEmptyKeyPool = errors.New("reve: key pool has no keys")
+
+// KeyStrategy selects the next key from a pool.
+type KeyStrategy int
+
+// Key selection strategies.
+const (
+	// RoundRobin cycles through the keys in order.
+	RoundRobin KeyStrategy = iota
+
+	// LeastUsed picks the key with the fewest requests in flight,
+	// then the fewest requests overall.
+	LeastUsed
+
+	// MostCredits picks the key with the most credits remaining. Keys
+	// whose balance is not yet known are tried first.
+	MostCredits
+)
+
+// String returns the strategy name.
+func (s KeyStrategy) String() string {
+	switch s {
+	case RoundRobin:
+		return "round-robin"
+	case LeastUsed:
+		return "least-used"
+	case MostCredits:
+		return "most-credits"
+	}
+	return fmt.Sprintf("KeyStrategy(%d)", int(s))
+}
+
+// PoolKey is one API key in a pool. Label identifies the key in results
+// and statistics so the secret itself is never reported.
+type PoolKey struct {
+	Label string
+	Key   string
+}
+
+// KeyPoolConfig configures a KeyPool.
+type KeyPoolConfig struct {
+	// Strategy selects the next key.
+	// Default: RoundRobin
+	Strategy KeyStrategy
+
+	// Cooldown is how long a rate-limited key is left out of rotation
+	// when the response has no Retry-After header.
+	// Default: 1 minute
+	Cooldown time.Duration
+
+	// ExhaustedCooldown is how long a key is left out of rotation after
+	// INSUFFICIENT_CREDITS.
+	// Default: 15 minutes
+	ExhaustedCooldown time.Duration
+}
+
+// KeyStats reports the state of one key in a pool.
+type KeyStats struct {
+	Label    string
+	Requests int
+	InFlight int
+
+	// CreditsRemaining is the last reported balance, or -1 if unknown.
+	CreditsRemaining int
+
+	// CoolingUntil is when the key returns to rotation. It is zero for
+	// keys in rotation.
+	CoolingUntil time.Time
+}
+
+// KeyPool spreads requests across several API keys. It implements
+// CredentialsProvider; the transport also reports each response back to
+// the pool to track credits and cool down throttled keys.
+type KeyPool struct {
+	cfg KeyPoolConfig
+
+	mu    sync.Mutex
+	slots []*poolSlot
+	next  int
+}
+
+type poolSlot struct {
+	PoolKey
+	requests  int
+	inFlight  int
+	remaining int
+	coolUntil time.Time
+}
+
+// NewKeyPool creates a pool of keys. Keys without a label are labelled
+// "key-1", "key-2" and so on.
+//
+// Example:
+//
+//	pool := transport.NewKeyPool([]transport.PoolKey{
+//		{Label: "team-a", Key: os.Getenv("REVE_KEY_A")},
+//		{Label: "team-b", Key: os.Getenv("REVE_KEY_B")},
+//	}, &transport.KeyPoolConfig{Strategy: transport.MostCredits})
+func NewKeyPool(keys []PoolKey, cfg *KeyPoolConfig) *KeyPool {
+	p := &KeyPool{}
+	if cfg != nil {
+		p.cfg = *cfg
+	}
+	if p.cfg.Cooldown <= 0 {
+		p.cfg.Cooldown = DefaultKeyCooldown
+	}
+	if p.cfg.ExhaustedCooldown <= 0 {
+		p.cfg.ExhaustedCooldown = DefaultExhaustedKeyCooldown
+	}
+	for i, k := range keys {
+		if k.Label == "" {
+			k.Label = fmt.Sprintf("key-%d", i+1)
+		}
+		p.slots = append(p.slots, &poolSlot{PoolKey: k, remaining: -1})
+	}
+	return p
+}
+
+// APIKey selects a key without tracking its response. The transport
+// uses the pool directly; APIKey is for callers that need a key outside
+// of a client.
+func (p *KeyPool) APIKey(context.Context) (string, error) {
+	s, err := p.acquire(time.Now())
+	if err != nil {
+		return "", err
+	}
+	p.mu.Lock()
+	s.inFlight--
+	p.mu.Unlock()
+	return s.Key, nil
+}
+
+// Stats returns the state of every key, in pool order.
+func (p *KeyPool) Stats() []KeyStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	stats := make([]KeyStats, len(p.slots))
+	for i, s := range p.slots {
+		stats[i] = KeyStats{
+			Label:            s.Label,
+			Requests:         s.requests,
+			InFlight:         s.inFlight,
+			CreditsRemaining: s.remaining,
+		}
+		if s.coolUntil.After(now) {
+			stats[i].CoolingUntil = s.coolUntil
+		}
+	}
+	return stats
+}
+
+// acquire selects a key and marks it in flight. If every key is cooling
+// down, the one that returns to rotation first is used.
+func (p *KeyPool) acquire(now time.Time) (*poolSlot, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.slots) == 0 {
+		return nil, ErrEmptyKeyPool
+	}
+
+	var best *poolSlot
+	switch p.cfg.Strategy {
+	case LeastUsed:
+		for _, s := range p.available(now) {
+			if best == nil || s.inFlight < best.inFlight ||
+				s.inFlight == best.inFlight && s.requests < best.requests {
+				best = s
+			}
+		}
+	case MostCredits:
+		for _, s := range p.available(now) {
+			if best == nil || credits(s) > credits(best) {
+				best = s
+			}
+		}
+	default:
+		for range p.slots {
+			s := p.slots[p.next%len(p.slots)]
+			p.next++
+			if !s.coolUntil.After(now) {
+				best = s
+				break
+			}
+		}
+	}
+
+	if best == nil {
+		for _, s := range p.slots {
+			if best == nil || s.coolUntil.Before(best.coolUntil) {
+				best = s
+			}
+		}
+	}
+	best.requests++
+	best.inFlight++
+	return best, nil
+}
+
+func (p *KeyPool) available(now time.Time) []*poolSlot {
+	var out []*poolSlot
+	for _, s := range p.slots {
+		if !s.coolUntil.After(now) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// inRotation reports whether any key is not cooling down.
+func (p *KeyPool) inRotation(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.available(now)) > 0
+}
+
+// credits ranks unknown balances above any known balance.
+func credits(s *poolSlot) int {
+	if s.remaining < 0 {
+		return math.MaxInt
+	}
+	return s.remaining
+}
+
+// release records the outcome of a request made with s. header is nil
+// if no response was received.
+func (p *KeyPool) release(s *poolSlot, header http.Header, body []byte, err error) {
+	remaining := -1
+	if v := header.Get("X-Reve-Credits-Remaining"); v != "" {
+		remaining, _ = strconv.Atoi(v)
+	} else if err == nil && len(body) > 0 {
+		var r struct {
+			CreditsRemaining *int `json:"credits_remaining"`
+		}
+		if json.Unmarshal(body, &r) == nil && r.CreditsRemaining != nil {
+			remaining = *r.CreditsRemaining
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s.inFlight--
+	if remaining >= 0 {
+		s.remaining = remaining
+	}
+
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		return
+	}
+	switch {
+	case apiErr.IsInsufficientFunds():
+		s.remaining = 0
+		s.coolUntil = time.Now().Add(p.cfg.ExhaustedCooldown)
+	case apiErr.IsRateLimit():
+		cooldown := p.cfg.Cooldown
+		if secs, err := strconv.Atoi(header.Get("Retry-After")); err == nil && secs > 0 {
+			cooldown = time.Duration(secs) * time.Second
+		}
+		s.coolUntil = time.Now().Add(cooldown)
+	}
+}
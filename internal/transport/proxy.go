@@ -0,0 +1,228 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/net/proxy"
+)
+
+// Dial defaults.
+const (
+	DefaultDialTimeout         = 30 * time.Second
+	DefaultKeepAlive           = 30 * time.Second
+	DefaultTLSHandshakeTimeout = 10 * time.Second
+)
+
+// DialOptions sets the connection timeouts of the transports built by
+// this package. Zero values use the defaults.
+type DialOptions struct {
+	// Timeout bounds establishing a connection. Through a SOCKS5 proxy
+	// it covers connecting to the proxy and the SOCKS handshake.
+	Timeout time.Duration
+
+	// KeepAlive is the TCP keep-alive period.
+	KeepAlive time.Duration
+
+	// TLSHandshakeTimeout bounds the TLS handshake with the API.
+	TLSHandshakeTimeout time.Duration
+}
+
+func (o *DialOptions) withDefaults() DialOptions {
+	var d DialOptions
+	if o != nil {
+		d = *o
+	}
+	if d.Timeout <= 0 {
+		d.Timeout = DefaultDialTimeout
+	}
+	if d.KeepAlive == 0 {
+		d.KeepAlive = DefaultKeepAlive
+	}
+	if d.TLSHandshakeTimeout <= 0 {
+		d.TLSHandshakeTimeout = DefaultTLSHandshakeTimeout
+	}
+	return d
+}
+
+func (o *DialOptions) dialer() *net.Dialer {
+	d := o.withDefaults()
+	return &net.Dialer{Timeout: d.Timeout, KeepAlive: d.KeepAlive}
+}
+
+// CreateProxyTransport creates a transport for an http, https, socks5 or
+// socks5h proxy URL. With socks5 the API host name is resolved locally;
+// with socks5h the proxy resolves it. opts may be nil.
+func CreateProxyTransport(proxyURL string, opts *DialOptions) (http.RoundTripper, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https":
+		t := newHTTPTransport(opts)
+		t.Proxy = http.ProxyURL(u)
+		return t, nil
+	case "socks5", "socks5h":
+		var auth *proxy.Auth
+		if u.User != nil {
+			password, _ := u.User.Password()
+			auth = &proxy.Auth{User: u.User.Username(), Password: password}
+		}
+		return createSOCKS5Transport(u.Host, auth, u.Scheme == "socks5h", opts)
+	}
+	return nil, fmt.Errorf("unsupported proxy scheme %q", u.Scheme)
+}
+
+// CreateHTTPProxyTransport creates a transport with HTTP proxy.
+func CreateHTTPProxyTransport(proxyURL string) (http.RoundTripper, error) {
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	t := newHTTPTransport(nil)
+	t.Proxy = http.ProxyURL(parsed)
+	return t, nil
+}
+
+// CreateSOCKS5ProxyTransport creates a transport with SOCKS5 proxy.
+// Host names are resolved by the proxy, as with socks5h URLs.
+func CreateSOCKS5ProxyTransport(addr, username, password string) (http.RoundTripper, error) {
+	var auth *proxy.Auth
+	if username != "" {
+		auth = &proxy.Auth{User: username, Password: password}
+	}
+	return createSOCKS5Transport(addr, auth, true, nil)
+}
+
+func createSOCKS5Transport(addr string, auth *proxy.Auth, remoteDNS bool, opts *DialOptions) (http.RoundTripper, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("socks5 proxy address: %w", err)
+	}
+	d := opts.withDefaults()
+	dialer, err := proxy.SOCKS5("tcp", addr, auth, opts.dialer())
+	if err != nil {
+		return nil, err
+	}
+	dial := contextDial(dialer)
+
+	t := newHTTPTransport(opts)
+	t.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+		defer cancel()
+		if !remoteDNS {
+			resolved, err := resolve(ctx, address)
+			if err != nil {
+				return nil, err
+			}
+			address = resolved
+		}
+		return dial(ctx, network, address)
+	}
+	return t, nil
+}
+
+// contextDial returns d's DialContext if it has one. Otherwise the dial
+// runs in a goroutine so that cancelling ctx at least returns promptly.
+func contextDial(d proxy.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	if cd, ok := d.(proxy.ContextDialer); ok {
+		return cd.DialContext
+	}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		type result struct {
+			conn net.Conn
+			err  error
+		}
+		done := make(chan result, 1)
+		go func() {
+			conn, err := d.Dial(network, address)
+			done <- result{conn, err}
+		}()
+		select {
+		case r := <-done:
+			return r.conn, r.err
+		case <-ctx.Done():
+			go func() {
+				if r := <-done; r.conn != nil {
+					r.conn.Close()
+				}
+			}()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// resolve replaces the host in address with its first IP address.
+func resolve(ctx context.Context, address string) (string, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", err
+	}
+	if net.ParseIP(host) != nil {
+		return address, nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return "", err
+	}
+	if len(addrs) == 0 {
+		return "", fmt.Errorf("no addresses for %s", host)
+	}
+	return net.JoinHostPort(addrs[0].IP.String(), port), nil
+}
+
+// CreateEnvProxyTransport creates a transport using environment proxy settings.
+func CreateEnvProxyTransport() http.RoundTripper {
+	return CreateEnvProxyTransportWith(nil)
+}
+
+// CreateEnvProxyTransportWith creates a transport using HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY. ALL_PROXY, which may be a socks5 or socks5h
+// URL, is used for schemes without a specific proxy. opts may be nil.
+func CreateEnvProxyTransportWith(opts *DialOptions) http.RoundTripper {
+	cfg := httpproxy.FromEnvironment()
+	all := os.Getenv("ALL_PROXY")
+	if all == "" {
+		all = os.Getenv("all_proxy")
+	}
+	if cfg.HTTPProxy == "" {
+		cfg.HTTPProxy = all
+	}
+	if cfg.HTTPSProxy == "" {
+		cfg.HTTPSProxy = all
+	}
+	proxyFunc := cfg.ProxyFunc()
+
+	t := newHTTPTransport(opts)
+	t.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+	return t
+}
+
+// CreateDefaultTransport creates a direct transport with the same
+// settings as the proxy transports. opts may be nil.
+func CreateDefaultTransport(opts *DialOptions) http.RoundTripper {
+	return newHTTPTransport(opts)
+}
+
+// newHTTPTransport returns the base settings shared by every transport
+// the SDK builds.
+func newHTTPTransport(opts *DialOptions) *http.Transport {
+	return &http.Transport{
+		DialContext:           opts.dialer().DialContext,
+		TLSClientConfig:       &tls.Config{MinVersion: tls.VersionTLS12},
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   opts.withDefaults().TLSHandshakeTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
@@ -0,0 +1,337 @@
+package transport
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Proxy pool defaults.
+const (
+	DefaultProxyHealthCheckInterval = 30 * time.Second
+	DefaultProxyHealthCheckTimeout  = 5 * time.Second
+)
+
+// ErrEmptyProxyPool is returned when a proxy pool is created without
+// proxies.
+var ErrEmptyProxyPool = errors.New("reve: proxy pool has no proxies")
+
+// ProxyStrategy selects the proxy for each request.
+type ProxyStrategy int
+
+// Proxy selection strategies.
+const (
+	// ProxyRoundRobin cycles through the healthy proxies in order.
+	ProxyRoundRobin ProxyStrategy = iota
+
+	// ProxyRandom picks a healthy proxy at random.
+	ProxyRandom
+)
+
+// String returns the strategy name.
+func (s ProxyStrategy) String() string {
+	switch s {
+	case ProxyRoundRobin:
+		return "round-robin"
+	case ProxyRandom:
+		return "random"
+	}
+	return fmt.Sprintf("ProxyStrategy(%d)", int(s))
+}
+
+// ProxyPoolConfig configures a ProxyPool.
+type ProxyPoolConfig struct {
+	// Strategy selects the proxy for each request.
+	// Default: ProxyRoundRobin
+	Strategy ProxyStrategy
+
+	// HealthCheckInterval is how often every proxy is probed by
+	// connecting to it. A negative value disables health checks; an
+	// unhealthy proxy is then only used when no healthy one is left.
+	// Default: 30 seconds
+	HealthCheckInterval time.Duration
+
+	// HealthCheckTimeout bounds each probe.
+	// Default: 5 seconds
+	HealthCheckTimeout time.Duration
+}
+
+// ProxyStats reports the state of one proxy in a pool.
+type ProxyStats struct {
+	// URL is the proxy URL with the password redacted.
+	URL      string
+	Healthy  bool
+	Requests int
+	Failures int
+
+	// LastError is the last connection error, or nil.
+	LastError error
+}
+
+// ProxyPool is an http.RoundTripper that spreads requests across several
+// proxies. A proxy that fails to connect is marked unhealthy and the
+// request is sent again through another proxy, provided nothing was
+// written to the failed connection. Health checks return proxies to
+// rotation.
+type ProxyPool struct {
+	cfg ProxyPoolConfig
+
+	mu      sync.Mutex
+	proxies []*poolProxy
+	next    int
+
+	start sync.Once
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+type poolProxy struct {
+	url       *url.URL
+	transport http.RoundTripper
+
+	healthy   bool
+	requests  int
+	failures  int
+	lastError error
+}
+
+// NewProxyPool creates a pool of http, https, socks5 or socks5h proxies.
+// cfg and opts may be nil.
+//
+// Example:
+//
+//	pool, err := transport.NewProxyPool([]string{
+//		"http://proxy-a:8080",
+//		"socks5h://proxy-b:1080",
+//	}, &transport.ProxyPoolConfig{Strategy: transport.ProxyRandom}, nil)
+func NewProxyPool(proxyURLs []string, cfg *ProxyPoolConfig, opts *DialOptions) (*ProxyPool, error) {
+	if len(proxyURLs) == 0 {
+		return nil, ErrEmptyProxyPool
+	}
+	p := &ProxyPool{}
+	if cfg != nil {
+		p.cfg = *cfg
+	}
+	if p.cfg.HealthCheckInterval == 0 {
+		p.cfg.HealthCheckInterval = DefaultProxyHealthCheckInterval
+	}
+	if p.cfg.HealthCheckTimeout <= 0 {
+		p.cfg.HealthCheckTimeout = DefaultProxyHealthCheckTimeout
+	}
+
+	for _, raw := range proxyURLs {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, err
+		}
+		t, err := CreateProxyTransport(raw, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", u.Redacted(), err)
+		}
+		p.proxies = append(p.proxies, &poolProxy{url: u, transport: t, healthy: true})
+	}
+	return p, nil
+}
+
+// RoundTrip sends req through a proxy from the pool.
+func (p *ProxyPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	p.start.Do(p.startHealthChecks)
+
+	tried := make(map[*poolProxy]bool, len(p.proxies))
+	for {
+		px := p.pick(tried)
+		tried[px] = true
+
+		var wrote atomic.Bool
+		trace := &httptrace.ClientTrace{
+			WroteHeaders: func() { wrote.Store(true) },
+		}
+		attempt := req.Clone(httptrace.WithClientTrace(req.Context(), trace))
+		if len(tried) > 1 && req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attempt.Body = body
+		}
+
+		resp, err := px.transport.RoundTrip(attempt)
+		p.report(px, err, req.Context().Err() != nil)
+		if err == nil {
+			return resp, nil
+		}
+
+		retryable := !wrote.Load() && req.Context().Err() == nil &&
+			(req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+		if !retryable || len(tried) == len(p.proxies) {
+			return nil, fmt.Errorf("proxy %s: %w", px.url.Redacted(), err)
+		}
+	}
+}
+
+// pick selects an untried proxy, preferring healthy ones.
+func (p *ProxyPool) pick(tried map[*poolProxy]bool) *poolProxy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var candidates []*poolProxy
+	for _, healthy := range []bool{true, false} {
+		for i := range p.proxies {
+			px := p.proxies[(p.next+i)%len(p.proxies)]
+			if px.healthy == healthy && !tried[px] {
+				candidates = append(candidates, px)
+			}
+		}
+		if len(candidates) > 0 {
+			break
+		}
+	}
+
+	px := candidates[0]
+	if p.cfg.Strategy == ProxyRandom {
+		px = candidates[rand.IntN(len(candidates))]
+	}
+	for i, q := range p.proxies {
+		if q == px {
+			p.next = i + 1
+		}
+	}
+	px.requests++
+	return px
+}
+
+// report records the outcome of a request. Errors caused by the request
+// being cancelled do not count against the proxy.
+func (p *ProxyPool) report(px *poolProxy, err error, cancelled bool) {
+	if err == nil || cancelled {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	px.healthy = false
+	px.failures++
+	px.lastError = err
+}
+
+// Stats returns the state of every proxy, in pool order.
+func (p *ProxyPool) Stats() []ProxyStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := make([]ProxyStats, len(p.proxies))
+	for i, px := range p.proxies {
+		stats[i] = ProxyStats{
+			URL:       px.url.Redacted(),
+			Healthy:   px.healthy,
+			Requests:  px.requests,
+			Failures:  px.failures,
+			LastError: px.lastError,
+		}
+	}
+	return stats
+}
+
+// CheckHealth probes every proxy once by connecting to it and updates
+// its health.
+func (p *ProxyPool) CheckHealth() {
+	var wg sync.WaitGroup
+	for _, px := range p.proxies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, err := net.DialTimeout("tcp", proxyAddr(px.url), p.cfg.HealthCheckTimeout)
+			if err == nil {
+				conn.Close()
+			}
+			p.mu.Lock()
+			px.healthy = err == nil
+			if err != nil {
+				px.lastError = err
+			}
+			p.mu.Unlock()
+		}()
+	}
+	wg.Wait()
+}
+
+// Close stops the health checks. The pool keeps sending requests.
+func (p *ProxyPool) Close() error {
+	p.start.Do(func() {})
+	p.mu.Lock()
+	stop := p.stop
+	p.stop = nil
+	p.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-p.done
+	}
+	return nil
+}
+
+// startHealthChecks runs CheckHealth every HealthCheckInterval until
+// Close. It starts with the first request.
+func (p *ProxyPool) startHealthChecks() {
+	if p.cfg.HealthCheckInterval < 0 {
+		return
+	}
+	p.mu.Lock()
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	stop, done := p.stop, p.done
+	p.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(p.cfg.HealthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.CheckHealth()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// withTLS returns an unstarted copy of the pool whose proxy transports
+// use cfg.
+func (p *ProxyPool) withTLS(cfg *tls.Config) (*ProxyPool, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	q := &ProxyPool{cfg: p.cfg}
+	for _, px := range p.proxies {
+		t, ok := ApplyTLS(px.transport, cfg)
+		if !ok {
+			return nil, false
+		}
+		c := *px
+		c.transport = t
+		q.proxies = append(q.proxies, &c)
+	}
+	return q, true
+}
+
+// proxyAddr returns the host:port of a proxy URL, adding the scheme's
+// default port.
+func proxyAddr(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	port := "1080"
+	switch u.Scheme {
+	case "http":
+		port = "80"
+	case "https":
+		port = "443"
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
@@ -0,0 +1,95 @@
+package transport
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ErrPinMismatch is returned when no certificate presented by the server
+// matches a configured public key pin.
+var ErrPinMismatch = errors.New("reve: server certificate does not match any pinned public key")
+
+// ApplyTLS returns a copy of rt that uses cfg for TLS connections.
+// It reports false if rt is not an *http.Transport or a *ProxyPool.
+func ApplyTLS(rt http.RoundTripper, cfg *tls.Config) (http.RoundTripper, bool) {
+	if p, ok := rt.(*ProxyPool); ok {
+		q, ok := p.withTLS(cfg)
+		if !ok {
+			return rt, false
+		}
+		return q, true
+	}
+	t, ok := rt.(*http.Transport)
+	if !ok {
+		return rt, false
+	}
+	t = t.Clone()
+	t.TLSClientConfig = cfg.Clone()
+	return t, true
+}
+
+// LoadRootCAs returns the system roots with the certificates from the
+// given PEM files added.
+func LoadRootCAs(pemFiles ...string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	for _, path := range pemFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("%s: no PEM certificates found", path)
+		}
+	}
+	return pool, nil
+}
+
+// ParsePin decodes a SHA-256 public key pin given as "sha256/<base64>"
+// or bare base64, as produced by
+//
+//	openssl x509 -pubkey -noout | openssl pkey -pubin -outform der |
+//		openssl dgst -sha256 -binary | base64
+func ParsePin(pin string) ([]byte, error) {
+	b64 := strings.TrimPrefix(pin, "sha256/")
+	sum, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil || len(sum) != sha256.Size {
+		return nil, fmt.Errorf("invalid pin %q: want sha256/ followed by a base64 SHA-256 digest", pin)
+	}
+	return sum, nil
+}
+
+// SPKIPin returns the pin for a certificate's public key.
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// VerifyPins returns a tls.Config.VerifyConnection function that accepts
+// a connection when any certificate in the verified chain matches one of
+// pins. It runs after normal certificate verification; certificates the
+// server sent that are not part of a verified chain are ignored.
+func VerifyPins(pins [][]byte) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		for _, chain := range cs.VerifiedChains {
+			for _, cert := range chain {
+				sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+				for _, pin := range pins {
+					if string(sum[:]) == string(pin) {
+						return nil
+					}
+				}
+			}
+		}
+		return fmt.Errorf("%w (host %s)", ErrPinMismatch, cs.ServerName)
+	}
+}
@@ -0,0 +1,57 @@
+package validator
+
+import (
+	"errors"
+
+	"github.com/shamspias/reve-go/types"
+)
+
+// Errors collects field errors into a types.ValidationError.
+type Errors struct {
+	fields []*types.FieldError
+}
+
+// Check records err for path. Nil errors are ignored.
+func (e *Errors) Check(path string, err error) {
+	if err == nil {
+		return
+	}
+	e.fields = append(e.fields, &types.FieldError{
+		Path:    path,
+		Code:    Code(err),
+		Message: err.Error(),
+		Err:     err,
+	})
+}
+
+// Err returns a *types.ValidationError, or nil if nothing was recorded.
+func (e *Errors) Err() error {
+	if len(e.fields) == 0 {
+		return nil
+	}
+	return &types.ValidationError{Errors: e.fields}
+}
+
+// Code returns the validation error code for a sentinel error.
+func Code(err error) string {
+	switch {
+	case errors.Is(err, ErrEmptyPrompt),
+		errors.Is(err, ErrEmptyInstruction),
+		errors.Is(err, ErrEmptyReferenceImage),
+		errors.Is(err, ErrNoReferenceImages):
+		return types.CodeRequired
+	case errors.Is(err, ErrPromptTooLong):
+		return types.CodeTooLong
+	case errors.Is(err, ErrTooManyReferenceImages):
+		return types.CodeTooMany
+	case errors.Is(err, ErrInvalidUpscaleFactor),
+		errors.Is(err, ErrInvalidScaling):
+		return types.CodeOutOfRange
+	case errors.Is(err, ErrRefOutOfBounds):
+		return types.CodeIndexOutOfBounds
+	case errors.Is(err, ErrVersionMismatch):
+		return types.CodeUnsupported
+	default:
+		return types.CodeInvalidValue
+	}
+}
@@ -1,7 +1,12 @@
 // Package validator provides request validation.
 package validator
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shamspias/reve-go/types"
+)
 
 // Validation errors.
 var (
@@ -12,8 +17,10 @@ var (
 	ErrNoReferenceImages      = errors.New("at least one reference image required")
 	ErrTooManyReferenceImages = errors.New("maximum 6 reference images allowed")
 	ErrInvalidAspectRatio     = errors.New("invalid aspect ratio")
-	ErrInvalidUpscaleFactor   = errors.New("upscale factor must be 2, 3, or 4")
+	ErrInvalidUpscaleFactor   = types.ErrInvalidUpscaleFactor
 	ErrInvalidScaling         = errors.New("test time scaling must be 1-15")
+	ErrRefOutOfBounds         = errors.New("image reference index out of bounds")
+	ErrVersionMismatch        = errors.New("model version not supported by endpoint")
 )
 
 // Constants
@@ -65,6 +72,17 @@ func ValidateReferenceImages(images []string) error {
 	return nil
 }
 
+// ValidateImageRefs checks that every referenced index has a matching
+// reference image.
+func ValidateImageRefs(refs []int, count int) error {
+	for _, ref := range refs {
+		if ref < 0 || ref >= count {
+			return fmt.Errorf("%w: <img>%d</img> with %d image(s)", ErrRefOutOfBounds, ref, count)
+		}
+	}
+	return nil
+}
+
 // ValidateAspectRatio validates an aspect ratio string.
 func ValidateAspectRatio(ratio string) error {
 	if ratio == "" {
@@ -82,6 +100,14 @@ func ValidateAspectRatio(ratio string) error {
 	return nil
 }
 
+// ValidateVersion checks that a model version belongs to an endpoint.
+func ValidateVersion(version types.ModelVersion, endpoint types.Endpoint) error {
+	if !version.ValidFor(endpoint) {
+		return fmt.Errorf("%w: %s cannot be used with %s", ErrVersionMismatch, version, endpoint.Path())
+	}
+	return nil
+}
+
 // ValidateUpscaleFactor validates an upscale factor.
 func ValidateUpscaleFactor(factor int) error {
 	if factor < 2 || factor > 4 {
@@ -0,0 +1,164 @@
+package mcp
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/shamspias/reve-go/image"
+	"github.com/shamspias/reve-go/types"
+)
+
+// Schema is a JSON Schema object.
+type Schema map[string]any
+
+// fieldDescriptions documents the JSON fields of the parameter structs.
+var fieldDescriptions = map[string]string{
+	"prompt":            "Text description of the desired image. In remix prompts, refer to input images with <img>N</img> tags, where N is the zero-based index in image_paths.",
+	"edit_instruction":  "How to edit the image, in natural language.",
+	"aspect_ratio":      "Aspect ratio of the output image.",
+	"version":           "Model version. Use latest-fast for cheaper, faster results.",
+	"postprocessing":    "Postprocessing steps applied to the output image.",
+	"test_time_scaling": "Quality/effort multiplier from 1 to 15. Higher values cost proportionally more credits.",
+}
+
+// paramsSchema derives an input schema from a parameter struct's JSON
+// tags. Fields without omitempty are required.
+func paramsSchema(v any, endpoint types.Endpoint) Schema {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	props := Schema{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		s := typeSchema(f.Type)
+		if desc, ok := fieldDescriptions[name]; ok {
+			s["description"] = desc
+		}
+		switch f.Type {
+		case reflect.TypeOf(types.AspectRatio("")):
+			s["enum"] = aspectRatios()
+		case reflect.TypeOf(types.ModelVersion("")):
+			s["enum"] = versions(endpoint)
+		}
+		props[name] = s
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	return Schema{
+		"type":       "object",
+		"properties": props,
+		"required":   required,
+	}
+}
+
+func typeSchema(t reflect.Type) Schema {
+	switch t.Kind() {
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.Slice:
+		return Schema{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Struct:
+		if t == reflect.TypeOf(types.Postprocess{}) {
+			return Schema{
+				"type": "object",
+				"properties": Schema{
+					"process":        Schema{"type": "string", "enum": []string{string(types.ProcessUpscale), string(types.ProcessRemoveBackground)}},
+					"upscale_factor": Schema{"type": "integer", "minimum": 2, "maximum": 4, "description": "Required for upscale."},
+				},
+				"required": []string{"process"},
+			}
+		}
+		return paramsSchema(reflect.New(t).Interface(), "")
+	}
+	return Schema{}
+}
+
+func aspectRatios() []string {
+	return []string{
+		string(types.Ratio16x9), string(types.Ratio9x16), string(types.Ratio3x2), string(types.Ratio2x3),
+		string(types.Ratio4x3), string(types.Ratio3x4), string(types.Ratio1x1), string(types.RatioAuto),
+	}
+}
+
+func versions(endpoint types.Endpoint) []string {
+	var out []string
+	for _, m := range types.Versions(endpoint) {
+		if !m.Deprecated {
+			out = append(out, string(m.Version))
+		}
+	}
+	return out
+}
+
+// replaceProperty swaps a base64 image field for a file path field.
+func replaceProperty(s Schema, from, to string, prop Schema) Schema {
+	props := s["properties"].(Schema)
+	delete(props, from)
+	props[to] = prop
+	required := s["required"].([]string)
+	for i, name := range required {
+		if name == from {
+			required[i] = to
+		}
+	}
+	return s
+}
+
+func outputProperties(s Schema) Schema {
+	props := s["properties"].(Schema)
+	props["output_name"] = Schema{"type": "string", "description": "Optional file name for the result, without directory."}
+	props["breadcrumb"] = Schema{"type": "string", "description": "Optional tracking ID."}
+	return s
+}
+
+func createSchema() Schema {
+	return outputProperties(paramsSchema(image.CreateParams{}, types.EndpointCreate))
+}
+
+func editSchema() Schema {
+	s := paramsSchema(image.EditParams{}, types.EndpointEdit)
+	return outputProperties(replaceProperty(s, "reference_image", "image_path",
+		Schema{"type": "string", "description": "Path to the image file to edit."}))
+}
+
+func remixSchema() Schema {
+	s := paramsSchema(image.RemixParams{}, types.EndpointRemix)
+	return outputProperties(replaceProperty(s, "reference_images", "image_paths", Schema{
+		"type":        "array",
+		"items":       Schema{"type": "string"},
+		"minItems":    1,
+		"maxItems":    6,
+		"description": "Paths to the input image files. The first is <img>0</img>, the second <img>1</img>, and so on.",
+	}))
+}
+
+func estimateSchema() Schema {
+	pp := typeSchema(reflect.TypeOf([]types.Postprocess{}))
+	pp["description"] = fieldDescriptions["postprocessing"]
+	return Schema{
+		"type": "object",
+		"properties": Schema{
+			"endpoint":          Schema{"type": "string", "enum": []string{string(types.EndpointCreate), string(types.EndpointEdit), string(types.EndpointRemix)}},
+			"fast":              Schema{"type": "boolean", "description": "Use the fast model (edit and remix only)."},
+			"test_time_scaling": Schema{"type": "number", "description": fieldDescriptions["test_time_scaling"]},
+			"postprocessing":    pp,
+			"count":             Schema{"type": "integer", "minimum": 1, "description": "Number of requests to estimate."},
+		},
+		"required": []string{"endpoint"},
+	}
+}
@@ -0,0 +1,273 @@
+// Package mcp exposes the Reve image tools over the Model Context
+// Protocol (MCP) using the stdio transport.
+//
+// The server offers four tools: create_image, edit_image, remix_images
+// and estimate_cost. Input schemas are derived from CreateParams,
+// EditParams and RemixParams, with base64 image fields replaced by file
+// paths. Generated images are written to an output directory and the
+// tool result reports their paths, request IDs and credits.
+//
+// # Usage
+//
+//	client := reve.NewClient(os.Getenv("REVE_API_KEY"))
+//	srv := mcp.NewServer(client.Images, mcp.WithOutputDir("./images"))
+//	if err := srv.Serve(ctx, os.Stdin, os.Stdout); err != nil {
+//		log.Fatal(err)
+//	}
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/shamspias/reve-go/image"
+	"github.com/shamspias/reve-go/types"
+)
+
+// ProtocolVersion is the MCP protocol revision implemented by the server.
+const ProtocolVersion = "2024-11-05"
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Tool describes an MCP tool.
+type Tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	InputSchema Schema `json:"inputSchema"`
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithOutputDir sets the directory results are written to.
+// Default: the current directory.
+func WithOutputDir(dir string) Option {
+	return func(s *Server) {
+		s.outputDir = dir
+	}
+}
+
+// WithOutputFormat sets the image format for results.
+// Default: types.FormatPNG.
+func WithOutputFormat(f types.OutputFormat) Option {
+	return func(s *Server) {
+		s.format = f
+	}
+}
+
+// WithServerInfo sets the name and version reported to clients.
+func WithServerInfo(name, version string) Option {
+	return func(s *Server) {
+		s.name = name
+		s.version = version
+	}
+}
+
+// Server is an MCP server backed by an image.Service.
+type Server struct {
+	svc       *image.Service
+	outputDir string
+	format    types.OutputFormat
+	name      string
+	version   string
+
+	writeMu sync.Mutex
+	enc     *json.Encoder
+}
+
+// NewServer creates an MCP server.
+func NewServer(svc *image.Service, opts ...Option) *Server {
+	s := &Server{
+		svc:       svc,
+		outputDir: ".",
+		format:    types.FormatPNG,
+		name:      "reve",
+		version:   "1.0.0",
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Tools returns the tools offered by the server.
+func (s *Server) Tools() []Tool {
+	return []Tool{
+		{
+			Name:        "create_image",
+			Description: "Generate an image from a text prompt with Reve and save it to the output directory.",
+			InputSchema: createSchema(),
+		},
+		{
+			Name:        "edit_image",
+			Description: "Edit an existing image file with a natural-language instruction and save the result.",
+			InputSchema: editSchema(),
+		},
+		{
+			Name:        "remix_images",
+			Description: "Combine up to six image files with a prompt that refers to them as <img>0</img>, <img>1</img>, ...",
+			InputSchema: remixSchema(),
+		},
+		{
+			Name:        "estimate_cost",
+			Description: "Estimate the credit cost of a Reve request before making it.",
+			InputSchema: estimateSchema(),
+		},
+	}
+}
+
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Serve reads newline-delimited JSON-RPC messages from r and writes
+// responses to w until r is exhausted or ctx is cancelled. Tool calls
+// run concurrently. When ctx is cancelled Serve cancels the calls still
+// running, waits for them and returns ctx.Err(), without waiting for
+// the pending read on r.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	s.enc = json.NewEncoder(w)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+		for scanner.Scan() {
+			select {
+			case lines <- bytes.Clone(scanner.Bytes()):
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	for {
+		var line []byte
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErr:
+			return err
+		case line = <-lines:
+		}
+		if len(line) == 0 {
+			continue
+		}
+
+		var msg rpcMessage
+		if err := json.Unmarshal(line, &msg); err != nil {
+			s.reply(nil, nil, &rpcError{Code: codeParseError, Message: err.Error()})
+			continue
+		}
+		if msg.Method == "" {
+			// Responses to server requests are not used.
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, rerr := s.handle(ctx, &msg)
+			if msg.ID != nil {
+				s.reply(msg.ID, result, rerr)
+			}
+		}()
+	}
+}
+
+func (s *Server) reply(id json.RawMessage, result any, rerr *rpcError) {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_ = s.enc.Encode(rpcResponse{JSONRPC: "2.0", ID: id, Result: result, Error: rerr})
+}
+
+func (s *Server) handle(ctx context.Context, msg *rpcMessage) (any, *rpcError) {
+	switch msg.Method {
+	case "initialize":
+		// The server implements a single revision, so it answers with
+		// that one whatever the client asks for; a client that cannot
+		// use it disconnects.
+		return map[string]any{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]string{"name": s.name, "version": s.version},
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		return map[string]any{"tools": s.Tools()}, nil
+	case "tools/call":
+		var p struct {
+			Name      string         `json:"name"`
+			Arguments map[string]any `json:"arguments"`
+		}
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		return s.callTool(ctx, p.Name, p.Arguments)
+	}
+	if msg.ID == nil {
+		// Notifications such as notifications/initialized need no reply.
+		return nil, nil
+	}
+	if msg.JSONRPC != "2.0" {
+		return nil, &rpcError{Code: codeInvalidRequest, Message: "jsonrpc must be 2.0"}
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not found", msg.Method)}
+}
+
+// toolResult is the MCP tools/call result.
+type toolResult struct {
+	Content []content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func textResult(v any) *toolResult {
+	data, _ := json.MarshalIndent(v, "", "  ")
+	return &toolResult{Content: []content{{Type: "text", Text: string(data)}}}
+}
+
+func errorResult(err error) *toolResult {
+	return &toolResult{Content: []content{{Type: "text", Text: err.Error()}}, IsError: true}
+}
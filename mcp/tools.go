@@ -0,0 +1,230 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shamspias/reve-go/image"
+	"github.com/shamspias/reve-go/types"
+)
+
+// ToolOutput is the JSON reported to the agent for a generated image.
+type ToolOutput struct {
+	Path             string `json:"path"`
+	RequestID        string `json:"request_id"`
+	Version          string `json:"version"`
+	ContentViolation bool   `json:"content_violation"`
+	CreditsUsed      int    `json:"credits_used"`
+	CreditsRemaining int    `json:"credits_remaining"`
+}
+
+func (s *Server) callTool(ctx context.Context, name string, args map[string]any) (any, *rpcError) {
+	if args == nil {
+		args = map[string]any{}
+	}
+
+	var (
+		out *ToolOutput
+		err error
+	)
+	switch name {
+	case "create_image":
+		out, err = s.createImage(ctx, args)
+	case "edit_image":
+		out, err = s.editImage(ctx, args)
+	case "remix_images":
+		out, err = s.remixImages(ctx, args)
+	case "estimate_cost":
+		cost, err := estimateCost(args)
+		if err != nil {
+			return errorResult(err), nil
+		}
+		return textResult(cost), nil
+	default:
+		return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown tool %q", name)}
+	}
+	if err != nil {
+		return errorResult(err), nil
+	}
+	return textResult(out), nil
+}
+
+func (s *Server) createImage(ctx context.Context, args map[string]any) (*ToolOutput, error) {
+	outputName, breadcrumb := takeString(args, "output_name"), takeString(args, "breadcrumb")
+	var params image.CreateParams
+	if err := decodeArgs(args, &params); err != nil {
+		return nil, err
+	}
+	params.Breadcrumb = breadcrumb
+
+	result, err := s.svc.CreateRaw(ctx, &params, s.format)
+	if err != nil {
+		return nil, err
+	}
+	return s.save("create", outputName, result)
+}
+
+func (s *Server) editImage(ctx context.Context, args map[string]any) (*ToolOutput, error) {
+	outputName, breadcrumb := takeString(args, "output_name"), takeString(args, "breadcrumb")
+	path := takeString(args, "image_path")
+	if path == "" {
+		return nil, errors.New("image_path is required")
+	}
+	img, err := types.NewImageFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+	args["reference_image"] = img.Base64()
+
+	var params image.EditParams
+	if err := decodeArgs(args, &params); err != nil {
+		return nil, err
+	}
+	params.Breadcrumb = breadcrumb
+
+	result, err := s.svc.EditRaw(ctx, &params, s.format)
+	if err != nil {
+		return nil, err
+	}
+	return s.save("edit", outputName, result)
+}
+
+func (s *Server) remixImages(ctx context.Context, args map[string]any) (*ToolOutput, error) {
+	outputName, breadcrumb := takeString(args, "output_name"), takeString(args, "breadcrumb")
+	paths, _ := args["image_paths"].([]any)
+	delete(args, "image_paths")
+	if len(paths) == 0 {
+		return nil, errors.New("image_paths is required")
+	}
+	images := make([]string, 0, len(paths))
+	for _, p := range paths {
+		path, ok := p.(string)
+		if !ok {
+			return nil, errors.New("image_paths must be strings")
+		}
+		img, err := types.NewImageFromFile(path)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, img.Base64())
+	}
+	args["reference_images"] = images
+
+	var params image.RemixParams
+	if err := decodeArgs(args, &params); err != nil {
+		return nil, err
+	}
+	params.Breadcrumb = breadcrumb
+
+	result, err := s.svc.RemixRaw(ctx, &params, s.format)
+	if err != nil {
+		return nil, err
+	}
+	return s.save("remix", outputName, result)
+}
+
+// save writes a result to the output directory.
+func (s *Server) save(prefix, name string, result *types.RawResult) (*ToolOutput, error) {
+	if name == "" {
+		name = prefix + "-" + result.RequestID
+	}
+	name = filepath.Base(name)
+	if filepath.Ext(name) == "" {
+		name += s.format.Extension()
+	}
+
+	if err := os.MkdirAll(s.outputDir, 0o755); err != nil {
+		return nil, err
+	}
+	path, err := filepath.Abs(filepath.Join(s.outputDir, name))
+	if err != nil {
+		return nil, err
+	}
+	if err := result.SaveTo(path); err != nil {
+		return nil, err
+	}
+
+	return &ToolOutput{
+		Path:             path,
+		RequestID:        result.RequestID,
+		Version:          result.Version,
+		ContentViolation: result.ContentViolation,
+		CreditsUsed:      result.CreditsUsed,
+		CreditsRemaining: result.CreditsRemaining,
+	}, nil
+}
+
+// costOutput is the JSON reported for estimate_cost.
+type costOutput struct {
+	Endpoint      string  `json:"endpoint"`
+	Count         int     `json:"count"`
+	CreditsEach   int     `json:"credits_each"`
+	TotalCredits  int     `json:"total_credits"`
+	EstimatedUSD  float64 `json:"estimated_usd"`
+	HumanReadable string  `json:"summary"`
+}
+
+func estimateCost(args map[string]any) (*costOutput, error) {
+	var p struct {
+		Endpoint        types.Endpoint      `json:"endpoint"`
+		Fast            bool                `json:"fast"`
+		TestTimeScaling float64             `json:"test_time_scaling"`
+		Postprocess     []types.Postprocess `json:"postprocessing"`
+		Count           int                 `json:"count"`
+	}
+	if err := decodeArgs(args, &p); err != nil {
+		return nil, err
+	}
+	if p.Count < 1 {
+		p.Count = 1
+	}
+
+	var cost image.Cost
+	switch p.Endpoint {
+	case types.EndpointCreate:
+		cost = image.EstimateCreate(p.TestTimeScaling, p.Postprocess)
+	case types.EndpointEdit:
+		cost = image.EstimateEdit(p.Fast, p.TestTimeScaling, p.Postprocess)
+	case types.EndpointRemix:
+		cost = image.EstimateRemix(p.Fast, p.TestTimeScaling, p.Postprocess)
+	default:
+		return nil, fmt.Errorf("unknown endpoint %q", p.Endpoint)
+	}
+
+	total := cost.TotalCredits * p.Count
+	return &costOutput{
+		Endpoint:      string(p.Endpoint),
+		Count:         p.Count,
+		CreditsEach:   cost.TotalCredits,
+		TotalCredits:  total,
+		EstimatedUSD:  float64(total) * image.CostPerCredit,
+		HumanReadable: image.FormatCredits(total),
+	}, nil
+}
+
+// decodeArgs converts tool arguments into a parameter struct.
+func decodeArgs(args map[string]any, v any) error {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// takeString removes a string argument that has no counterpart in the
+// parameter structs.
+func takeString(args map[string]any, key string) string {
+	v, _ := args[key].(string)
+	delete(args, key)
+	return v
+}
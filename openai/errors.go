@@ -0,0 +1,70 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/shamspias/reve-go/internal/transport"
+	"github.com/shamspias/reve-go/types"
+)
+
+// errorBody is the OpenAI error object.
+type errorBody struct {
+	Message string  `json:"message"`
+	Type    string  `json:"type"`
+	Param   *string `json:"param"`
+	Code    string  `json:"code,omitempty"`
+}
+
+func invalidParam(param, message string) *errorBody {
+	return &errorBody{Message: message, Type: "invalid_request_error", Param: &param}
+}
+
+func writeError(w http.ResponseWriter, status int, body *errorBody) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]*errorBody{"error": body})
+}
+
+// paramNames maps Reve JSON fields to their OpenAI equivalents.
+var paramNames = map[string]string{
+	"edit_instruction": "prompt",
+	"reference_image":  "image",
+	"reference_images": "image",
+	"aspect_ratio":     "size",
+	"version":          "model",
+}
+
+// translateError maps SDK errors to an OpenAI status and error body.
+func translateError(err error) (int, *errorBody) {
+	var verr *types.ValidationError
+	if errors.As(err, &verr) && len(verr.Errors) > 0 {
+		fe := verr.Errors[0]
+		param := fe.Path
+		if name, ok := paramNames[param]; ok {
+			param = name
+		}
+		return http.StatusBadRequest, &errorBody{Message: fe.Error(), Type: "invalid_request_error", Param: &param, Code: fe.Code}
+	}
+
+	var apiErr *transport.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.IsAuthError():
+			return http.StatusUnauthorized, &errorBody{Message: apiErr.Message, Type: "invalid_request_error", Code: "invalid_api_key"}
+		case apiErr.IsInsufficientFunds():
+			return http.StatusTooManyRequests, &errorBody{Message: apiErr.Message, Type: "insufficient_quota", Code: "insufficient_quota"}
+		case apiErr.IsRateLimit():
+			return http.StatusTooManyRequests, &errorBody{Message: apiErr.Message, Type: "requests", Code: "rate_limit_exceeded"}
+		case apiErr.IsContentViolation():
+			return http.StatusBadRequest, &errorBody{Message: apiErr.Message, Type: "invalid_request_error", Code: "content_policy_violation"}
+		case apiErr.StatusCode >= 500:
+			return http.StatusInternalServerError, &errorBody{Message: apiErr.Message, Type: "server_error"}
+		default:
+			return apiErr.StatusCode, &errorBody{Message: apiErr.Message, Type: "invalid_request_error", Code: string(apiErr.Code)}
+		}
+	}
+
+	return http.StatusBadGateway, &errorBody{Message: err.Error(), Type: "server_error"}
+}
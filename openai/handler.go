@@ -0,0 +1,257 @@
+// Package openai serves the OpenAI Images API on top of the Reve SDK.
+//
+// The handler accepts /v1/images/generations and /v1/images/edits
+// request shapes and translates them to image.Service calls, so tools
+// that speak the OpenAI protocol can use Reve without changes.
+//
+// # Usage
+//
+//	client := reve.NewClient(os.Getenv("REVE_API_KEY"))
+//	h := openai.NewHandler(client.Images,
+//		openai.WithPublicURL("https://images.example.com"),
+//	)
+//	log.Fatal(http.ListenAndServe(":8080", h))
+//
+// # Mapping
+//
+//   - size is mapped to the nearest supported AspectRatio ("auto" is kept)
+//   - response_format "b64_json" returns base64 data, "url" returns a
+//     link served by the handler for the configured TTL, within the
+//     limits set by WithFileStoreLimit
+//   - output_format selects PNG, JPEG or WebP (default PNG)
+//   - background "transparent" adds a remove_background step
+//   - quality "hd" or "high" doubles test time scaling
+//   - edits with several images are sent to Remix
+//   - Reve errors are returned as OpenAI error bodies
+package openai
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shamspias/reve-go/image"
+	"github.com/shamspias/reve-go/types"
+)
+
+// Default handler settings.
+const (
+	DefaultFileTTL   = time.Hour
+	DefaultMaxImages = 4
+	DefaultMaxUpload = 32 << 20
+
+	// DefaultMaxFiles and DefaultMaxFileBytes bound the images kept for
+	// "url" responses.
+	DefaultMaxFiles     = 256
+	DefaultMaxFileBytes = 256 << 20
+)
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithPublicURL sets the external base URL used for "url" responses.
+// Default: derived from the incoming request.
+func WithPublicURL(u string) Option {
+	return func(h *Handler) {
+		h.publicURL = strings.TrimRight(u, "/")
+	}
+}
+
+// WithFileTTL sets how long images for "url" responses are kept.
+func WithFileTTL(d time.Duration) Option {
+	return func(h *Handler) {
+		h.ttl = d
+	}
+}
+
+// WithFileStoreLimit bounds the images kept for "url" responses to
+// maxFiles images and maxBytes bytes in total. When a new image does not
+// fit, the images closest to expiry are dropped first.
+func WithFileStoreLimit(maxFiles int, maxBytes int64) Option {
+	return func(h *Handler) {
+		h.maxFiles = maxFiles
+		h.maxFileBytes = maxBytes
+	}
+}
+
+// WithMaxImages limits n per request.
+func WithMaxImages(n int) Option {
+	return func(h *Handler) {
+		h.maxImages = n
+	}
+}
+
+// WithAPIKeys requires callers to send one of keys as a bearer token.
+// Keys are trimmed and empty keys are ignored; if none is left, every
+// request is refused. By default the handler does not check
+// Authorization.
+func WithAPIKeys(keys ...string) Option {
+	return func(h *Handler) {
+		h.auth = true
+		for _, k := range keys {
+			if k = strings.TrimSpace(k); k != "" {
+				h.keys = append(h.keys, []byte(k))
+			}
+		}
+	}
+}
+
+// Handler translates OpenAI Images API requests to Reve.
+type Handler struct {
+	svc       *image.Service
+	mux       *http.ServeMux
+	publicURL string
+	ttl       time.Duration
+	maxImages int
+	auth      bool
+	keys      [][]byte
+
+	maxFiles     int
+	maxFileBytes int64
+
+	mu        sync.Mutex
+	files     map[string]storedFile
+	filesSize int64
+}
+
+type storedFile struct {
+	data        []byte
+	contentType string
+	expires     time.Time
+}
+
+// NewHandler creates a handler backed by svc.
+func NewHandler(svc *image.Service, opts ...Option) *Handler {
+	h := &Handler{
+		svc:       svc,
+		mux:       http.NewServeMux(),
+		ttl:       DefaultFileTTL,
+		maxImages: DefaultMaxImages,
+		files:     make(map[string]storedFile),
+
+		maxFiles:     DefaultMaxFiles,
+		maxFileBytes: DefaultMaxFileBytes,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	h.mux.HandleFunc("POST /v1/images/generations", h.generations)
+	h.mux.HandleFunc("POST /v1/images/edits", h.edits)
+	h.mux.HandleFunc("GET /v1/images/files/{name}", h.file)
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.auth && !strings.HasPrefix(r.URL.Path, "/v1/images/files/") {
+		if !h.authorized(r) {
+			writeError(w, http.StatusUnauthorized, &errorBody{
+				Message: "Incorrect API key provided.",
+				Type:    "invalid_request_error",
+				Code:    "invalid_api_key",
+			})
+			return
+		}
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// authorized reports whether r carries one of the configured keys as a
+// bearer token. Every key is compared in constant time.
+func (h *Handler) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return false
+	}
+	match := 0
+	for _, k := range h.keys {
+		match |= subtle.ConstantTimeCompare([]byte(token), k)
+	}
+	return match == 1
+}
+
+// errStoreFull is returned by store for an image larger than the whole
+// file store.
+var errStoreFull = errors.New("image is too large for a url response; use response_format b64_json")
+
+// store keeps data for a "url" response and returns its URL.
+func (h *Handler) store(r *http.Request, data []byte, format types.OutputFormat) (string, error) {
+	size := int64(len(data))
+	if size > h.maxFileBytes {
+		return "", errStoreFull
+	}
+
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	name := hex.EncodeToString(id) + format.Extension()
+
+	now := time.Now()
+	h.mu.Lock()
+	for k, f := range h.files {
+		if now.After(f.expires) {
+			h.drop(k, f)
+		}
+	}
+	for len(h.files) > 0 && (len(h.files) >= h.maxFiles || h.filesSize+size > h.maxFileBytes) {
+		h.dropOldest()
+	}
+	h.files[name] = storedFile{data: data, contentType: string(format), expires: now.Add(h.ttl)}
+	h.filesSize += size
+	h.mu.Unlock()
+
+	return h.baseURL(r) + "/v1/images/files/" + name, nil
+}
+
+// dropOldest removes the stored file closest to expiry. h.mu must be
+// held.
+func (h *Handler) dropOldest() {
+	var (
+		oldest string
+		of     storedFile
+	)
+	for k, f := range h.files {
+		if oldest == "" || f.expires.Before(of.expires) {
+			oldest, of = k, f
+		}
+	}
+	h.drop(oldest, of)
+}
+
+func (h *Handler) drop(name string, f storedFile) {
+	delete(h.files, name)
+	h.filesSize -= int64(len(f.data))
+}
+
+func (h *Handler) baseURL(r *http.Request) string {
+	if h.publicURL != "" {
+		return h.publicURL
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if fwd := r.Header.Get("X-Forwarded-Proto"); fwd != "" {
+		scheme = fwd
+	}
+	return scheme + "://" + r.Host
+}
+
+func (h *Handler) file(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	h.mu.Lock()
+	f, ok := h.files[name]
+	h.mu.Unlock()
+	if !ok || time.Now().After(f.expires) {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", f.contentType)
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	_, _ = w.Write(f.data)
+}
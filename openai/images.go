@@ -0,0 +1,349 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shamspias/reve-go/image"
+	"github.com/shamspias/reve-go/types"
+)
+
+// GenerationRequest is the body of POST /v1/images/generations. The
+// form fields of POST /v1/images/edits are read into it too.
+type GenerationRequest struct {
+	Prompt         string `json:"prompt"`
+	Model          string `json:"model,omitempty"`
+	N              int    `json:"n,omitempty"`
+	Size           string `json:"size,omitempty"`
+	Quality        string `json:"quality,omitempty"`
+	ResponseFormat string `json:"response_format,omitempty"`
+	OutputFormat   string `json:"output_format,omitempty"`
+	Background     string `json:"background,omitempty"`
+	User           string `json:"user,omitempty"`
+}
+
+// ImagesResponse is the response body for both endpoints.
+type ImagesResponse struct {
+	Created int64       `json:"created"`
+	Data    []ImageData `json:"data"`
+}
+
+// ImageData is a single generated image.
+type ImageData struct {
+	B64JSON       string `json:"b64_json,omitempty"`
+	URL           string `json:"url,omitempty"`
+	RevisedPrompt string `json:"revised_prompt,omitempty"`
+}
+
+// options are the request fields shared by generations and edits.
+type options struct {
+	n              int
+	ratio          types.AspectRatio
+	version        types.ModelVersion
+	scaling        float64
+	postprocess    []types.Postprocess
+	responseFormat string
+	format         types.OutputFormat
+	breadcrumb     string
+}
+
+func (h *Handler) parseOptions(req *GenerationRequest) (*options, *errorBody) {
+	o := &options{n: req.N, breadcrumb: req.User}
+	if o.n == 0 {
+		o.n = 1
+	}
+	if o.n < 1 || o.n > h.maxImages {
+		return nil, invalidParam("n", "n must be between 1 and "+strconv.Itoa(h.maxImages))
+	}
+
+	ratio, ok := NearestAspectRatio(req.Size)
+	if !ok {
+		return nil, invalidParam("size", "Invalid size '"+req.Size+"'")
+	}
+	o.ratio = ratio
+
+	if v := types.ModelVersion(req.Model); v != "" {
+		if _, known := types.LookupVersion(v); known {
+			o.version = v
+		}
+	}
+
+	switch strings.ToLower(req.Quality) {
+	case "hd", "high":
+		o.scaling = 2
+	}
+
+	switch req.ResponseFormat {
+	case "", "b64_json", "url":
+		o.responseFormat = req.ResponseFormat
+		if o.responseFormat == "" {
+			o.responseFormat = "b64_json"
+		}
+	default:
+		return nil, invalidParam("response_format", "response_format must be 'url' or 'b64_json'")
+	}
+
+	switch strings.ToLower(req.OutputFormat) {
+	case "", "png":
+		o.format = types.FormatPNG
+	case "jpeg", "jpg":
+		o.format = types.FormatJPEG
+	case "webp":
+		o.format = types.FormatWebP
+	default:
+		return nil, invalidParam("output_format", "output_format must be 'png', 'jpeg' or 'webp'")
+	}
+
+	if req.Background == "transparent" {
+		o.postprocess = append(o.postprocess, types.RemoveBackground())
+	}
+	return o, nil
+}
+
+func (h *Handler) generations(w http.ResponseWriter, r *http.Request) {
+	var req GenerationRequest
+	r.Body = http.MaxBytesReader(w, r.Body, DefaultMaxUpload)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, bodyErrorStatus(err), &errorBody{Message: "Invalid JSON body: " + err.Error(), Type: "invalid_request_error"})
+		return
+	}
+	o, ebody := h.parseOptions(&req)
+	if ebody != nil {
+		writeError(w, http.StatusBadRequest, ebody)
+		return
+	}
+
+	params := &image.CreateParams{
+		Prompt:          req.Prompt,
+		AspectRatio:     o.ratio,
+		Version:         o.version,
+		Postprocess:     o.postprocess,
+		TestTimeScaling: o.scaling,
+		Breadcrumb:      o.breadcrumb,
+	}
+	if o.version != "" && !o.version.ValidFor(types.EndpointCreate) {
+		params.Version = ""
+	}
+	h.respond(w, r, o, func(ctx context.Context) (*types.RawResult, error) {
+		return h.svc.CreateRaw(ctx, params, o.format)
+	})
+}
+
+func (h *Handler) edits(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, DefaultMaxUpload)
+	if err := r.ParseMultipartForm(DefaultMaxUpload); err != nil {
+		writeError(w, bodyErrorStatus(err), &errorBody{Message: "Invalid multipart body: " + err.Error(), Type: "invalid_request_error"})
+		return
+	}
+	form := r.MultipartForm
+
+	var files []*multipart.FileHeader
+	for _, field := range []string{"image", "image[]"} {
+		files = append(files, form.File[field]...)
+	}
+	if len(files) == 0 {
+		writeError(w, http.StatusBadRequest, invalidParam("image", "'image' is a required property"))
+		return
+	}
+	images := make([]string, 0, len(files))
+	for _, fh := range files {
+		data, err := readFile(fh)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, invalidParam("image", err.Error()))
+			return
+		}
+		images = append(images, base64.StdEncoding.EncodeToString(data))
+	}
+
+	req := GenerationRequest{
+		Prompt:         r.FormValue("prompt"),
+		Model:          r.FormValue("model"),
+		Size:           r.FormValue("size"),
+		Quality:        r.FormValue("quality"),
+		ResponseFormat: r.FormValue("response_format"),
+		OutputFormat:   r.FormValue("output_format"),
+		Background:     r.FormValue("background"),
+		User:           r.FormValue("user"),
+	}
+	req.N, _ = strconv.Atoi(r.FormValue("n"))
+	o, ebody := h.parseOptions(&req)
+	if ebody != nil {
+		writeError(w, http.StatusBadRequest, ebody)
+		return
+	}
+	prompt := req.Prompt
+
+	if len(images) == 1 {
+		params := &image.EditParams{
+			Instruction:     prompt,
+			ReferenceImage:  images[0],
+			AspectRatio:     o.ratio,
+			Version:         o.version,
+			Postprocess:     o.postprocess,
+			TestTimeScaling: o.scaling,
+			Breadcrumb:      o.breadcrumb,
+		}
+		if o.version != "" && !o.version.ValidFor(types.EndpointEdit) {
+			params.Version = ""
+		}
+		h.respond(w, r, o, func(ctx context.Context) (*types.RawResult, error) {
+			return h.svc.EditRaw(ctx, params, o.format)
+		})
+		return
+	}
+
+	params := &image.RemixParams{
+		Prompt:          prompt,
+		ReferenceImages: images,
+		AspectRatio:     o.ratio,
+		Version:         o.version,
+		Postprocess:     o.postprocess,
+		TestTimeScaling: o.scaling,
+		Breadcrumb:      o.breadcrumb,
+	}
+	if o.version != "" && !o.version.ValidFor(types.EndpointRemix) {
+		params.Version = ""
+	}
+	h.respond(w, r, o, func(ctx context.Context) (*types.RawResult, error) {
+		return h.svc.RemixRaw(ctx, params, o.format)
+	})
+}
+
+// errContentViolation marks a result flagged by the content policy.
+var errContentViolation = errors.New("content policy violation")
+
+// respond runs call o.n times concurrently and writes the response. The
+// first failure cancels the calls still running, so no further credits
+// are spent on a request that will be answered with an error.
+func (h *Handler) respond(w http.ResponseWriter, r *http.Request, o *options, call func(context.Context) (*types.RawResult, error)) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	results := make([]*types.RawResult, o.n)
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		failErr  error
+	)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := call(ctx)
+			if err == nil && res.ContentViolation {
+				err = errContentViolation
+			}
+			if err != nil {
+				failOnce.Do(func() {
+					failErr = err
+					cancel()
+				})
+				return
+			}
+			results[i] = res
+		}()
+	}
+	wg.Wait()
+
+	if errors.Is(failErr, errContentViolation) {
+		writeError(w, http.StatusBadRequest, &errorBody{
+			Message: "Your request was rejected as a result of our safety system.",
+			Type:    "invalid_request_error",
+			Code:    "content_policy_violation",
+		})
+		return
+	}
+	if failErr != nil {
+		status, body := translateError(failErr)
+		writeError(w, status, body)
+		return
+	}
+
+	resp := ImagesResponse{Created: time.Now().Unix()}
+	for _, res := range results {
+		data := ImageData{}
+		if o.responseFormat == "url" {
+			u, err := h.store(r, res.Data, o.format)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, &errorBody{Message: err.Error(), Type: "server_error"})
+				return
+			}
+			data.URL = u
+		} else {
+			data.B64JSON = base64.StdEncoding.EncodeToString(res.Data)
+		}
+		resp.Data = append(resp.Data, data)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// bodyErrorStatus returns 413 if err comes from a body over
+// DefaultMaxUpload and 400 otherwise.
+func bodyErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+func readFile(fh *multipart.FileHeader) ([]byte, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("uploaded image is empty")
+	}
+	return data, nil
+}
+
+// NearestAspectRatio maps an OpenAI size such as "1792x1024" to the
+// closest supported aspect ratio. Empty sizes and "auto" map to
+// RatioAuto.
+func NearestAspectRatio(size string) (types.AspectRatio, bool) {
+	if size == "" || size == "auto" {
+		return types.RatioAuto, true
+	}
+	ws, hs, ok := strings.Cut(strings.ToLower(size), "x")
+	if !ok {
+		return "", false
+	}
+	width, err1 := strconv.Atoi(ws)
+	height, err2 := strconv.Atoi(hs)
+	if err1 != nil || err2 != nil || width <= 0 || height <= 0 {
+		return "", false
+	}
+
+	target := math.Log(float64(width) / float64(height))
+	best := types.Ratio1x1
+	bestDiff := math.Inf(1)
+	for _, ratio := range []types.AspectRatio{
+		types.Ratio16x9, types.Ratio9x16, types.Ratio3x2, types.Ratio2x3,
+		types.Ratio4x3, types.Ratio3x4, types.Ratio1x1,
+	} {
+		rw, rh := ratio.Dimensions()
+		diff := math.Abs(math.Log(float64(rw)/float64(rh)) - target)
+		if diff < bestDiff {
+			best, bestDiff = ratio, diff
+		}
+	}
+	return best, true
+}
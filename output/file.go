@@ -0,0 +1,79 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidKey is returned for keys that are absolute or escape the
+// sink's root.
+var ErrInvalidKey = errors.New("output: invalid key")
+
+// FileSink writes images below a root directory. Each write goes to a
+// temporary file in the target directory that is renamed into place, so
+// readers never see partial images.
+type FileSink struct {
+	root     string
+	fileMode fs.FileMode
+	dirMode  fs.FileMode
+}
+
+// NewFileSink creates a sink rooted at dir.
+func NewFileSink(dir string) *FileSink {
+	return &FileSink{root: dir, fileMode: 0o644, dirMode: 0o755}
+}
+
+// Root returns the root directory.
+func (s *FileSink) Root() string {
+	return s.root
+}
+
+// Path returns the filesystem path for key.
+func (s *FileSink) Path(key string) (string, error) {
+	if !fs.ValidPath(key) || strings.Contains(key, `\`) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+// Put writes data to the file for key.
+func (s *FileSink) Put(ctx context.Context, key string, data []byte, _ Meta) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, err := s.Path(key)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, s.dirMode); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), s.fileMode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
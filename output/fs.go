@@ -0,0 +1,48 @@
+package output
+
+import (
+	"context"
+	"io/fs"
+	"path"
+)
+
+// WriteFileFS is a filesystem that can write files. Paths use the io/fs
+// conventions: slash-separated and unrooted.
+type WriteFileFS interface {
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+}
+
+// MkdirAllFS is implemented by filesystems that need parent directories
+// created before writing.
+type MkdirAllFS interface {
+	MkdirAll(name string, perm fs.FileMode) error
+}
+
+// FSSink stores images in a WriteFileFS, such as an os.Root or an
+// in-house object store wrapper.
+type FSSink struct {
+	fsys WriteFileFS
+}
+
+// NewFSSink creates a sink backed by fsys.
+func NewFSSink(fsys WriteFileFS) *FSSink {
+	return &FSSink{fsys: fsys}
+}
+
+// Put writes data to key in the underlying filesystem.
+func (s *FSSink) Put(ctx context.Context, key string, data []byte, _ Meta) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !fs.ValidPath(key) || key == "." {
+		return &fs.PathError{Op: "put", Path: key, Err: ErrInvalidKey}
+	}
+	if m, ok := s.fsys.(MkdirAllFS); ok {
+		if dir := path.Dir(key); dir != "." {
+			if err := m.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+		}
+	}
+	return s.fsys.WriteFile(key, data, 0o644)
+}
@@ -0,0 +1,184 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemorySink keeps images in memory. It implements fs.FS so stored
+// images can be served with http.FileServerFS or walked with fs.WalkDir.
+type MemorySink struct {
+	mu    sync.RWMutex
+	files map[string]*memFile
+}
+
+type memFile struct {
+	data    []byte
+	meta    Meta
+	modTime time.Time
+}
+
+// NewMemorySink creates an empty in-memory sink.
+func NewMemorySink() *MemorySink {
+	return &MemorySink{files: make(map[string]*memFile)}
+}
+
+// Put stores a copy of data under key.
+func (s *MemorySink) Put(ctx context.Context, key string, data []byte, meta Meta) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !fs.ValidPath(key) || key == "." {
+		return &fs.PathError{Op: "put", Path: key, Err: ErrInvalidKey}
+	}
+	f := &memFile{data: bytes.Clone(data), meta: meta, modTime: meta.Time}
+	if f.modTime.IsZero() {
+		f.modTime = time.Now()
+	}
+	s.mu.Lock()
+	s.files[key] = f
+	s.mu.Unlock()
+	return nil
+}
+
+// Get returns the data and metadata stored under key.
+func (s *MemorySink) Get(key string) ([]byte, Meta, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f, ok := s.files[key]
+	if !ok {
+		return nil, Meta{}, false
+	}
+	return f.data, f.meta, true
+}
+
+// Keys returns the stored keys in sorted order.
+func (s *MemorySink) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]string, 0, len(s.files))
+	for k := range s.files {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Len returns the number of stored images.
+func (s *MemorySink) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.files)
+}
+
+// Open implements fs.FS. Directories are synthesized from key prefixes.
+func (s *MemorySink) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if f, ok := s.files[name]; ok {
+		return &openFile{info: fileInfo{name: path.Base(name), size: int64(len(f.data)), modTime: f.modTime}, r: bytes.NewReader(f.data)}, nil
+	}
+
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+	seen := make(map[string]fs.DirEntry)
+	for key, f := range s.files {
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		child, _, isDir := strings.Cut(rest, "/")
+		if _, dup := seen[child]; dup {
+			continue
+		}
+		info := fileInfo{name: child, size: int64(len(f.data)), modTime: f.modTime}
+		if isDir {
+			info = fileInfo{name: child, dir: true}
+		}
+		seen[child] = fs.FileInfoToDirEntry(info)
+	}
+	if len(seen) == 0 && name != "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	entries := make([]fs.DirEntry, 0, len(seen))
+	for _, e := range seen {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return &openDir{info: fileInfo{name: path.Base(name), dir: true}, entries: entries}, nil
+}
+
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (fi fileInfo) Name() string       { return fi.name }
+func (fi fileInfo) Size() int64        { return fi.size }
+func (fi fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi fileInfo) IsDir() bool        { return fi.dir }
+func (fi fileInfo) Sys() any           { return nil }
+
+func (fi fileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+type openFile struct {
+	info fileInfo
+	r    *bytes.Reader
+}
+
+func (f *openFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *openFile) Read(p []byte) (int, error) { return f.r.Read(p) }
+func (f *openFile) Close() error               { return nil }
+
+func (f *openFile) Seek(offset int64, whence int) (int64, error) {
+	return f.r.Seek(offset, whence)
+}
+
+type openDir struct {
+	info    fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *openDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *openDir) Close() error               { return nil }
+
+func (d *openDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *openDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
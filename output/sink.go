@@ -0,0 +1,94 @@
+// Package output provides pluggable destinations for generated images.
+//
+// A Sink stores image bytes under a key. Keys are usually produced from a
+// naming Template such as "{date}/{breadcrumb}/{request_id}.{ext}".
+// FileSink writes to the local filesystem, MemorySink keeps images in
+// memory, and FSSink adapts any filesystem with a WriteFile method.
+//
+// # Usage
+//
+//	sink := output.NewFileSink("./images")
+//	client := reve.NewClient(apiKey,
+//		reve.WithOutputSink(sink, "{date}/{request_id}.{ext}"),
+//	)
+//
+//	// Results are now written to ./images as they arrive.
+//	result, err := client.Images.Create(ctx, params)
+package output
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/shamspias/reve-go/types"
+)
+
+// Meta describes an image being stored.
+type Meta struct {
+	Endpoint    types.Endpoint
+	RequestID   string
+	Version     string
+	Breadcrumb  string
+	Prompt      string
+	ContentType string
+	CreditsUsed int
+
+	// Index is the position in a batch, or zero.
+	Index int
+
+	// Time is when the image was received. Zero means now.
+	Time time.Time
+}
+
+// Sink stores generated images.
+// Implementations must be safe for concurrent use.
+type Sink interface {
+	Put(ctx context.Context, key string, data []byte, meta Meta) error
+}
+
+// SinkFunc adapts a function to the Sink interface.
+type SinkFunc func(ctx context.Context, key string, data []byte, meta Meta) error
+
+// Put calls f.
+func (f SinkFunc) Put(ctx context.Context, key string, data []byte, meta Meta) error {
+	return f(ctx, key, data, meta)
+}
+
+// PutResult decodes a JSON result and stores it under a key produced by
+// tmpl. It returns the key.
+func PutResult(ctx context.Context, s Sink, tmpl Template, result *types.Result, meta Meta) (string, error) {
+	data, err := result.Bytes()
+	if err != nil {
+		return "", err
+	}
+	meta.RequestID = result.RequestID
+	meta.Version = result.Version
+	meta.CreditsUsed = result.CreditsUsed
+	meta.ContentType = http.DetectContentType(data)
+	return put(ctx, s, tmpl, data, meta)
+}
+
+// PutRaw stores a raw result under a key produced by tmpl and returns
+// the key.
+func PutRaw(ctx context.Context, s Sink, tmpl Template, result *types.RawResult, meta Meta) (string, error) {
+	meta.RequestID = result.RequestID
+	meta.Version = result.Version
+	meta.CreditsUsed = result.CreditsUsed
+	meta.ContentType = result.ContentType
+	if meta.ContentType == "" {
+		meta.ContentType = http.DetectContentType(result.Data)
+	}
+	return put(ctx, s, tmpl, result.Data, meta)
+}
+
+func put(ctx context.Context, s Sink, tmpl Template, data []byte, meta Meta) (string, error) {
+	if meta.Time.IsZero() {
+		meta.Time = time.Now()
+	}
+	key := tmpl.Key(meta)
+	if err := s.Put(ctx, key, data, meta); err != nil {
+		return "", err
+	}
+	return key, nil
+}
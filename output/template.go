@@ -0,0 +1,71 @@
+package output
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/shamspias/reve-go/types"
+)
+
+// DefaultTemplate is used when a Template is empty.
+const DefaultTemplate Template = "{date}/{request_id}.{ext}"
+
+// Template names stored images. Placeholders are replaced with values
+// from Meta:
+//
+//	{date}        2006-01-02
+//	{time}        150405
+//	{endpoint}    create, edit or remix
+//	{request_id}  the API request ID
+//	{breadcrumb}  the breadcrumb, or "none"
+//	{version}     the model version
+//	{index}       the batch index
+//	{ext}         png, jpeg or webp
+//
+// Values are made safe for use as a single path element.
+type Template string
+
+// Key returns the key for meta.
+func (t Template) Key(meta Meta) string {
+	if t == "" {
+		t = DefaultTemplate
+	}
+	r := strings.NewReplacer(
+		"{date}", meta.Time.Format("2006-01-02"),
+		"{time}", meta.Time.Format("150405"),
+		"{endpoint}", clean(string(meta.Endpoint), "image"),
+		"{request_id}", clean(meta.RequestID, "unknown"),
+		"{breadcrumb}", clean(meta.Breadcrumb, "none"),
+		"{version}", clean(meta.Version, "unknown"),
+		"{index}", strconv.Itoa(meta.Index),
+		"{ext}", extension(meta.ContentType),
+	)
+	return r.Replace(string(t))
+}
+
+// extension returns the file extension, without the dot, for a
+// Content-Type such as "image/jpeg; charset=binary". Unknown types map
+// to png.
+func extension(contentType string) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+	return strings.TrimPrefix(types.OutputFormat(contentType).Extension(), ".")
+}
+
+// clean makes s safe as a single path element.
+func clean(s, fallback string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r == '/' || r == '\\' || r == ':' || r < 0x20:
+			return '_'
+		}
+		return r
+	}, s)
+	s = strings.Trim(s, ". ")
+	if s == "" {
+		return fallback
+	}
+	return s
+}
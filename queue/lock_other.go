@@ -0,0 +1,11 @@
+//go:build !unix && !windows
+
+package queue
+
+import "os"
+
+// lockFile opens path. File locks are not available on this platform, so
+// the caller must make sure only one process opens the queue.
+func lockFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// errSharingViolation is ERROR_SHARING_VIOLATION, which the syscall
+// package does not define.
+const errSharingViolation syscall.Errno = 32
+
+// lockFile opens path without sharing, so no other handle to it can be
+// opened until the file is closed or the process exits.
+func lockFile(path string) (*os.File, error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	h, err := syscall.CreateFile(name, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil,
+		syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		if errors.Is(err, errSharingViolation) {
+			return nil, fmt.Errorf("%w: %s", ErrLocked, path)
+		}
+		return nil, fmt.Errorf("queue: locking %s: %w", path, err)
+	}
+	return os.NewFile(uintptr(h), path), nil
+}
@@ -0,0 +1,680 @@
+// Package queue provides a durable, file-backed job queue for image
+// generation and a worker that drains it through an image.Service.
+//
+// Jobs are stored in an append-only JSON-lines log. A job is logged in
+// full when it is enqueued and every later change appends only its state,
+// so parameters holding base64 images are written once; on open the log
+// is replayed and the last record for each job wins. The log is compacted
+// automatically once it holds many more records than live jobs, or
+// explicitly with Compact. A lock file next to the log keeps a second
+// process from opening it.
+//
+// # Usage
+//
+//	q, err := queue.Open("jobs.log")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer q.Close()
+//
+//	id, err := q.EnqueueCreate(&image.CreateParams{Prompt: "A lighthouse"}, types.FormatPNG)
+//
+//	w := queue.NewWorker(q, client.Images,
+//		queue.WithConcurrency(2),
+//		queue.WithSink(output.NewFileSink("./out"), ""),
+//	)
+//	err = w.Run(ctx)
+package queue
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shamspias/reve-go/image"
+	"github.com/shamspias/reve-go/types"
+)
+
+// Queue errors.
+var (
+	ErrNotFound  = errors.New("queue: job not found")
+	ErrJobActive = errors.New("queue: job is pending or running")
+	ErrClosed    = errors.New("queue: closed")
+	ErrNoParams  = errors.New("queue: job has no parameters")
+	ErrLocked    = errors.New("queue: log file is in use by another process")
+
+	// ErrUnusable is returned after the log file could not be reopened
+	// following a compaction. Reopen the queue with Open.
+	ErrUnusable = errors.New("queue: log file unusable")
+)
+
+// compactMin is the number of log records below which the log is never
+// compacted automatically.
+const compactMin = 1000
+
+// Kind is the image operation a job performs.
+type Kind string
+
+// Job kinds.
+const (
+	KindCreate Kind = "create"
+	KindEdit   Kind = "edit"
+	KindRemix  Kind = "remix"
+)
+
+// State is the lifecycle state of a job.
+type State string
+
+// Job states.
+const (
+	StatePending State = "pending"
+	StateRunning State = "running"
+	StateDone    State = "done"
+	StateDead    State = "dead"
+)
+
+// Job is a queued image operation. Exactly one of Create, Edit and Remix
+// is set.
+type Job struct {
+	ID     string              `json:"id"`
+	Kind   Kind                `json:"kind"`
+	Create *image.CreateParams `json:"create,omitempty"`
+	Edit   *image.EditParams   `json:"edit,omitempty"`
+	Remix  *image.RemixParams  `json:"remix,omitempty"`
+
+	// Format is the requested image format. Default: types.FormatPNG.
+	Format types.OutputFormat `json:"format,omitempty"`
+
+	// Breadcrumb is sent with the request. The parameter structs do not
+	// serialize their own Breadcrumb field.
+	Breadcrumb string `json:"breadcrumb,omitempty"`
+
+	State     State     `json:"state"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+	NotBefore time.Time `json:"not_before,omitzero"`
+
+	// RequestID and CreditsUsed are set as soon as the request succeeds,
+	// and Output when the job completes. Output is the key the result was
+	// stored under in the worker's sink.
+	RequestID   string `json:"request_id,omitempty"`
+	CreditsUsed int    `json:"credits_used,omitempty"`
+	Output      string `json:"output,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// record is one line of the log. "put" records hold a whole job, "state"
+// records the mutable fields of a job already in the log and "del"
+// records the ID of a removed job.
+type record struct {
+	Op    string    `json:"op"`
+	Job   *Job      `json:"job,omitempty"`
+	State *jobState `json:"state,omitempty"`
+	ID    string    `json:"id,omitempty"`
+}
+
+// jobState is the part of a Job that changes after it is enqueued.
+type jobState struct {
+	ID          string    `json:"id"`
+	State       State     `json:"state"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"last_error,omitempty"`
+	NotBefore   time.Time `json:"not_before,omitzero"`
+	RequestID   string    `json:"request_id,omitempty"`
+	CreditsUsed int       `json:"credits_used,omitempty"`
+	Output      string    `json:"output,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func stateOf(job *Job) *jobState {
+	return &jobState{
+		ID:          job.ID,
+		State:       job.State,
+		Attempts:    job.Attempts,
+		LastError:   job.LastError,
+		NotBefore:   job.NotBefore,
+		RequestID:   job.RequestID,
+		CreditsUsed: job.CreditsUsed,
+		Output:      job.Output,
+		UpdatedAt:   job.UpdatedAt,
+	}
+}
+
+// Queue is a durable job queue. It is safe for concurrent use; only one
+// process may open a log file at a time, which Open enforces with a lock
+// on path + ".lock".
+type Queue struct {
+	path string
+	logf func(format string, args ...any)
+	lock *os.File
+
+	mu      sync.Mutex
+	file    *os.File
+	jobs    map[string]*Job
+	records int
+	closed  bool
+	broken  error
+	notify  chan struct{}
+
+	// compactAfter postpones automatic compaction after a failure.
+	compactAfter int
+}
+
+// Option configures a Queue.
+type Option func(*Queue)
+
+// WithErrorLogger sets a logger for errors that do not fail the
+// operation that caused them, such as a failed automatic compaction.
+func WithErrorLogger(logf func(format string, args ...any)) Option {
+	return func(q *Queue) {
+		q.logf = logf
+	}
+}
+
+// Open opens or creates the queue stored at path. It fails with
+// ErrLocked if another process has the queue open. Jobs that were
+// running when the previous process stopped are returned to pending,
+// except those whose request had already succeeded, which are
+// dead-lettered with ErrUnsaved rather than billed again. A record torn
+// by a crash at the end of the log is discarded and cut off the file.
+func Open(path string, opts ...Option) (*Queue, error) {
+	q := &Queue{
+		path:   path,
+		jobs:   make(map[string]*Job),
+		notify: make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	lock, err := lockFile(path + ".lock")
+	if err != nil {
+		return nil, err
+	}
+	q.lock = lock
+
+	end, terminated, err := q.load()
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+	if err := repairTail(f, end, terminated); err != nil {
+		f.Close()
+		lock.Close()
+		return nil, fmt.Errorf("queue: repairing %s: %w", path, err)
+	}
+	q.file = f
+
+	for _, job := range q.sorted([]State{StateRunning}) {
+		next := *job
+		if next.RequestID != "" {
+			next.State = StateDead
+			next.LastError = ErrUnsaved.Error()
+		} else {
+			next.State = StatePending
+		}
+		if err := q.put(&next); err != nil {
+			f.Close()
+			lock.Close()
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+// load replays the log. It returns the offset just past the last
+// complete record and whether the data up to there ends with a newline.
+func (q *Queue) load() (int64, bool, error) {
+	data, err := os.ReadFile(q.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, true, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	var end int64
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		next := end + int64(len(line)) + 1
+		if len(bytes.TrimSpace(line)) == 0 {
+			end = min(next, int64(len(data)))
+			continue
+		}
+		var rec record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			if i == len(lines)-1 {
+				// A torn final write from a crash; drop it.
+				break
+			}
+			return 0, false, fmt.Errorf("queue: %s:%d: %w", q.path, i+1, err)
+		}
+		q.apply(rec)
+		q.records++
+		end = min(next, int64(len(data)))
+	}
+	return end, end == 0 || data[end-1] == '\n', nil
+}
+
+// repairTail truncates f to end, dropping a torn final record, and makes
+// sure the file ends with a newline so the next record starts on a line
+// of its own.
+func repairTail(f *os.File, end int64, terminated bool) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() > end {
+		if err := f.Truncate(end); err != nil {
+			return err
+		}
+	}
+	if terminated {
+		return nil
+	}
+	if _, err := f.Write([]byte{'\n'}); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func (q *Queue) apply(rec record) {
+	switch rec.Op {
+	case "put":
+		if rec.Job != nil {
+			q.jobs[rec.Job.ID] = rec.Job
+		}
+	case "state":
+		if rec.State == nil {
+			return
+		}
+		if job, ok := q.jobs[rec.State.ID]; ok {
+			job.State = rec.State.State
+			job.Attempts = rec.State.Attempts
+			job.LastError = rec.State.LastError
+			job.NotBefore = rec.State.NotBefore
+			job.RequestID = rec.State.RequestID
+			job.CreditsUsed = rec.State.CreditsUsed
+			job.Output = rec.State.Output
+			job.UpdatedAt = rec.State.UpdatedAt
+		}
+	case "del":
+		delete(q.jobs, rec.ID)
+	}
+}
+
+// append writes a record to the log and syncs it. Once the record is
+// written, a failed automatic compaction is logged rather than returned.
+// The caller must hold q.mu.
+func (q *Queue) append(rec record) error {
+	if q.broken != nil {
+		return q.broken
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := q.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	if err := q.file.Sync(); err != nil {
+		return err
+	}
+	q.records++
+	if q.records > compactMin && q.records > 4*len(q.jobs) && q.records >= q.compactAfter {
+		if err := q.compact(); err != nil {
+			q.compactAfter = q.records + compactMin
+			if q.logf != nil {
+				q.logf("queue: compacting %s: %v", q.path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// put stores a job and logs it: in full if it is new, otherwise only
+// its state. Jobs already in the queue must keep their parameters. The
+// caller must hold q.mu.
+func (q *Queue) put(job *Job) error {
+	if q.closed {
+		return ErrClosed
+	}
+	if q.broken != nil {
+		return q.broken
+	}
+	job.UpdatedAt = time.Now().UTC()
+	rec := record{Op: "put", Job: job}
+	if _, ok := q.jobs[job.ID]; ok {
+		rec = record{Op: "state", State: stateOf(job)}
+	}
+	q.jobs[job.ID] = job
+	return q.append(rec)
+}
+
+// Compact rewrites the log so it holds one record per live job.
+func (q *Queue) Compact() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	if q.broken != nil {
+		return q.broken
+	}
+	return q.compact()
+}
+
+func (q *Queue) compact() error {
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), filepath.Base(q.path)+".compact-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, job := range q.sorted(nil) {
+		if err := enc.Encode(record{Op: "put", Job: job}); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), q.path); err != nil {
+		return err
+	}
+
+	// The old handle now refers to the unlinked log; writing to it would
+	// lose records, so the queue stops accepting writes if the new file
+	// cannot be opened.
+	q.file.Close()
+	f, err := os.OpenFile(q.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		q.file = nil
+		q.broken = fmt.Errorf("%w: %v", ErrUnusable, err)
+		return q.broken
+	}
+	q.file = f
+	q.records = len(q.jobs)
+	return nil
+}
+
+// Close closes the log file and releases the lock.
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	var err error
+	if q.file != nil {
+		err = q.file.Close()
+	}
+	return errors.Join(err, q.lock.Close())
+}
+
+// Enqueue adds a job and returns its ID. The parameters are validated
+// first; ID, State and timestamps are filled in by the queue.
+func (q *Queue) Enqueue(job Job) (string, error) {
+	var err error
+	switch {
+	case job.Create != nil:
+		job.Kind, err = KindCreate, job.Create.Validate()
+	case job.Edit != nil:
+		job.Kind, err = KindEdit, job.Edit.Validate()
+	case job.Remix != nil:
+		job.Kind, err = KindRemix, job.Remix.Validate()
+	default:
+		return "", ErrNoParams
+	}
+	if err != nil {
+		return "", err
+	}
+	if job.Format == "" {
+		job.Format = types.FormatPNG
+	}
+
+	now := time.Now().UTC()
+	job.ID = newID()
+	job.State = StatePending
+	job.Attempts = 0
+	job.LastError = ""
+	job.NotBefore = time.Time{}
+	job.CreatedAt = now
+
+	q.mu.Lock()
+	err = q.put(&job)
+	q.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+	q.wake()
+	return job.ID, nil
+}
+
+// EnqueueCreate queues a create request.
+func (q *Queue) EnqueueCreate(params *image.CreateParams, format types.OutputFormat) (string, error) {
+	if params == nil {
+		return "", ErrNoParams
+	}
+	p := *params
+	return q.Enqueue(Job{Create: &p, Format: format, Breadcrumb: p.Breadcrumb})
+}
+
+// EnqueueEdit queues an edit request.
+func (q *Queue) EnqueueEdit(params *image.EditParams, format types.OutputFormat) (string, error) {
+	if params == nil {
+		return "", ErrNoParams
+	}
+	p := *params
+	return q.Enqueue(Job{Edit: &p, Format: format, Breadcrumb: p.Breadcrumb})
+}
+
+// EnqueueRemix queues a remix request.
+func (q *Queue) EnqueueRemix(params *image.RemixParams, format types.OutputFormat) (string, error) {
+	if params == nil {
+		return "", ErrNoParams
+	}
+	p := *params
+	return q.Enqueue(Job{Remix: &p, Format: format, Breadcrumb: p.Breadcrumb})
+}
+
+// Get returns a copy of a job.
+func (q *Queue) Get(id string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// List returns copies of the jobs in the given states, oldest first.
+// With no states, all jobs are returned.
+func (q *Queue) List(states ...State) []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var out []Job
+	for _, job := range q.sorted(states) {
+		out = append(out, *job)
+	}
+	return out
+}
+
+// Counts returns the number of jobs in each state.
+func (q *Queue) Counts() map[State]int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	counts := make(map[State]int)
+	for _, job := range q.jobs {
+		counts[job.State]++
+	}
+	return counts
+}
+
+// Retry returns a dead or done job to pending with its attempts reset.
+func (q *Queue) Retry(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if job.State == StatePending || job.State == StateRunning {
+		return ErrJobActive
+	}
+	next := *job
+	next.State = StatePending
+	next.Attempts = 0
+	next.LastError = ""
+	next.NotBefore = time.Time{}
+	if err := q.put(&next); err != nil {
+		return err
+	}
+	q.wake()
+	return nil
+}
+
+// RetryDead returns every dead job to pending and reports how many
+// were requeued.
+func (q *Queue) RetryDead() (int, error) {
+	n := 0
+	for _, job := range q.List(StateDead) {
+		if err := q.Retry(job.ID); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Purge removes jobs in the given states and reports how many were
+// removed. With no states, done and dead jobs are removed. Running jobs
+// are never purged.
+func (q *Queue) Purge(states ...State) (int, error) {
+	if len(states) == 0 {
+		states = []State{StateDone, StateDead}
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return 0, ErrClosed
+	}
+	n := 0
+	for _, job := range q.sorted(states) {
+		if job.State == StateRunning {
+			continue
+		}
+		delete(q.jobs, job.ID)
+		if err := q.append(record{Op: "del", ID: job.ID}); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// sorted returns the jobs in the given states ordered by creation time.
+// The caller must hold q.mu.
+func (q *Queue) sorted(states []State) []*Job {
+	var out []*Job
+	for _, job := range q.jobs {
+		if len(states) == 0 || hasState(states, job.State) {
+			out = append(out, job)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+func hasState(states []State, s State) bool {
+	for _, st := range states {
+		if st == s {
+			return true
+		}
+	}
+	return false
+}
+
+// claim marks the oldest runnable pending job as running and returns a
+// copy of it. The second result reports whether any jobs are pending,
+// including ones waiting out a retry delay.
+func (q *Queue) claim(now time.Time) (*Job, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	pending := q.sorted([]State{StatePending})
+	for _, job := range pending {
+		if job.NotBefore.After(now) {
+			continue
+		}
+		next := *job
+		next.State = StateRunning
+		next.Attempts++
+		if err := q.put(&next); err != nil {
+			return nil, true, err
+		}
+		claimed := next
+		return &claimed, true, nil
+	}
+	return nil, len(pending) > 0, nil
+}
+
+// update applies fn to a stored job and logs the result.
+func (q *Queue) update(id string, fn func(*Job)) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	next := *job
+	fn(&next)
+	return q.put(&next)
+}
+
+// wake signals a waiting worker that jobs are available.
+func (q *Queue) wake() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,353 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shamspias/reve-go/image"
+	"github.com/shamspias/reve-go/internal/transport"
+	"github.com/shamspias/reve-go/output"
+	"github.com/shamspias/reve-go/types"
+)
+
+// Worker defaults.
+const (
+	DefaultConcurrency  = 1
+	DefaultMaxAttempts  = 5
+	DefaultBackoff      = 5 * time.Second
+	DefaultMaxBackoff   = 10 * time.Minute
+	DefaultPollInterval = time.Second
+)
+
+// Worker errors recorded in Job.LastError.
+var (
+	// ErrContentViolation is recorded for jobs whose result was flagged
+	// by the content policy. Such jobs are dead-lettered without
+	// retrying.
+	ErrContentViolation = errors.New("queue: content policy violation")
+
+	// ErrUnsaved is recorded for jobs whose request succeeded but whose
+	// result was not saved. Such jobs are dead-lettered with their
+	// RequestID and CreditsUsed set; retrying them bills again.
+	ErrUnsaved = errors.New("queue: result not saved")
+)
+
+// WorkerOption configures a Worker.
+type WorkerOption func(*Worker)
+
+// WithConcurrency sets how many jobs run at once. Default: 1.
+func WithConcurrency(n int) WorkerOption {
+	return func(w *Worker) {
+		if n > 0 {
+			w.concurrency = n
+		}
+	}
+}
+
+// WithMaxAttempts sets how many times a job is tried before it is
+// dead-lettered. Default: 5.
+func WithMaxAttempts(n int) WorkerOption {
+	return func(w *Worker) {
+		if n > 0 {
+			w.maxAttempts = n
+		}
+	}
+}
+
+// WithBackoff sets the delay before the first retry and its upper bound.
+// The delay doubles with each attempt.
+func WithBackoff(min, max time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.backoff = min
+		w.maxBackoff = max
+	}
+}
+
+// WithPollInterval sets how often an idle worker checks for jobs that
+// became runnable, for example after a retry delay.
+func WithPollInterval(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		if d > 0 {
+			w.pollInterval = d
+		}
+	}
+}
+
+// WithSink stores completed results in sink under a key produced by
+// tmpl, or output.DefaultTemplate if tmpl is empty. The job's kind,
+// breadcrumb and prompt are passed as metadata. Without a sink, results
+// are discarded and only their metadata is recorded.
+func WithSink(sink output.Sink, tmpl output.Template) WorkerOption {
+	return func(w *Worker) {
+		w.sink = sink
+		w.naming = tmpl
+	}
+}
+
+// WithLogger sets a logger for job failures.
+func WithLogger(logf func(format string, args ...any)) WorkerOption {
+	return func(w *Worker) {
+		w.logf = logf
+	}
+}
+
+// Worker drains a Queue through an image.Service.
+type Worker struct {
+	queue        *Queue
+	svc          *image.Service
+	sink         output.Sink
+	naming       output.Template
+	concurrency  int
+	maxAttempts  int
+	backoff      time.Duration
+	maxBackoff   time.Duration
+	pollInterval time.Duration
+	logf         func(format string, args ...any)
+}
+
+// NewWorker creates a worker for q.
+func NewWorker(q *Queue, svc *image.Service, opts ...WorkerOption) *Worker {
+	w := &Worker{
+		queue:        q,
+		svc:          svc,
+		concurrency:  DefaultConcurrency,
+		maxAttempts:  DefaultMaxAttempts,
+		backoff:      DefaultBackoff,
+		maxBackoff:   DefaultMaxBackoff,
+		pollInterval: DefaultPollInterval,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Run processes jobs until ctx is cancelled. Jobs interrupted by the
+// cancellation are returned to pending without counting the attempt;
+// results that were already billed are still saved.
+// If a job's outcome cannot be written to the queue, Run stops and
+// returns the error, so the job is not billed again as if it never ran.
+func (w *Worker) Run(ctx context.Context) error {
+	return w.run(ctx, false)
+}
+
+// Drain processes jobs until none are pending, waiting out retry delays,
+// and then returns.
+func (w *Worker) Drain(ctx context.Context) error {
+	return w.run(ctx, true)
+}
+
+func (w *Worker) run(parent context.Context, drain bool) (err error) {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	sem := make(chan struct{}, w.concurrency)
+	var (
+		wg      sync.WaitGroup
+		failMu  sync.Mutex
+		failErr error
+	)
+	defer func() {
+		wg.Wait()
+		failMu.Lock()
+		defer failMu.Unlock()
+		if failErr != nil {
+			err = failErr
+		}
+	}()
+
+	finished := make(chan struct{}, w.concurrency)
+	for {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil
+		}
+
+		job, pending, err := w.queue.claim(time.Now())
+		if err != nil {
+			<-sem
+			return err
+		}
+		if job == nil {
+			<-sem
+			if drain && !pending && len(sem) == 0 {
+				return nil
+			}
+			timer := time.NewTimer(w.pollInterval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil
+			case <-w.queue.notify:
+			case <-finished:
+			case <-timer.C:
+			}
+			timer.Stop()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := w.process(ctx, job); err != nil {
+				failMu.Lock()
+				if failErr == nil {
+					failErr = err
+				}
+				failMu.Unlock()
+				cancel()
+			}
+			<-sem
+			select {
+			case finished <- struct{}{}:
+			default:
+			}
+		}()
+	}
+}
+
+// process runs a job and records its outcome. It returns an error only
+// if the outcome could not be recorded.
+//
+// Once the request has succeeded its request ID and credits are recorded
+// before the result is saved, and a result that cannot be saved
+// dead-letters the job, so a paid request is never run again unless the
+// job is retried explicitly.
+func (w *Worker) process(ctx context.Context, job *Job) error {
+	result, err := w.execute(ctx, job)
+	if err == nil && result.ContentViolation {
+		err = ErrContentViolation
+	}
+	if err == nil {
+		if err := w.record(job, func(j *Job) {
+			j.RequestID = result.RequestID
+			j.CreditsUsed = result.CreditsUsed
+		}); err != nil {
+			return err
+		}
+	}
+
+	var location string
+	if err == nil && w.sink != nil {
+		location, err = w.save(context.WithoutCancel(ctx), job, result)
+		if err != nil {
+			w.log("queue: job %s: saving result of request %s: %v", job.ID, result.RequestID, err)
+			return w.record(job, func(j *Job) {
+				j.State = StateDead
+				j.LastError = fmt.Sprintf("%v: %v", ErrUnsaved, err)
+			})
+		}
+	}
+
+	if err != nil && ctx.Err() != nil {
+		// Interrupted by shutdown: give the attempt back.
+		return w.record(job, func(j *Job) {
+			j.State = StatePending
+			j.Attempts--
+		})
+	}
+
+	if err == nil {
+		return w.record(job, func(j *Job) {
+			j.State = StateDone
+			j.LastError = ""
+			j.Output = location
+		})
+	}
+
+	w.log("queue: job %s attempt %d failed: %v", job.ID, job.Attempts, err)
+	return w.record(job, func(j *Job) {
+		j.LastError = err.Error()
+		if !retryable(err) || j.Attempts >= w.maxAttempts {
+			j.State = StateDead
+			return
+		}
+		j.State = StatePending
+		j.NotBefore = time.Now().Add(w.delay(j.Attempts))
+	})
+}
+
+// record applies fn to the stored job. A failure leaves the job marked
+// running, so it is logged and returned to stop the worker.
+func (w *Worker) record(job *Job, fn func(*Job)) error {
+	if err := w.queue.update(job.ID, fn); err != nil {
+		w.log("queue: job %s: recording outcome: %v", job.ID, err)
+		return fmt.Errorf("queue: job %s: recording outcome: %w", job.ID, err)
+	}
+	return nil
+}
+
+// save stores result in the sink and returns its key.
+func (w *Worker) save(ctx context.Context, job *Job, result *types.RawResult) (string, error) {
+	meta := output.Meta{Endpoint: types.Endpoint(job.Kind), Breadcrumb: job.Breadcrumb}
+	switch {
+	case job.Create != nil:
+		meta.Prompt = job.Create.Prompt
+	case job.Edit != nil:
+		meta.Prompt = job.Edit.Instruction
+	case job.Remix != nil:
+		meta.Prompt = job.Remix.Prompt
+	}
+	return output.PutRaw(ctx, w.sink, w.naming, result, meta)
+}
+
+func (w *Worker) log(format string, args ...any) {
+	if w.logf != nil {
+		w.logf(format, args...)
+	}
+}
+
+func (w *Worker) execute(ctx context.Context, job *Job) (*types.RawResult, error) {
+	format := job.Format
+	if format == "" {
+		format = types.FormatPNG
+	}
+	switch {
+	case job.Create != nil:
+		p := *job.Create
+		p.Breadcrumb = job.Breadcrumb
+		return w.svc.CreateRaw(ctx, &p, format)
+	case job.Edit != nil:
+		p := *job.Edit
+		p.Breadcrumb = job.Breadcrumb
+		return w.svc.EditRaw(ctx, &p, format)
+	case job.Remix != nil:
+		p := *job.Remix
+		p.Breadcrumb = job.Breadcrumb
+		return w.svc.RemixRaw(ctx, &p, format)
+	}
+	return nil, ErrNoParams
+}
+
+// delay returns the backoff before the given retry attempt.
+func (w *Worker) delay(attempt int) time.Duration {
+	d := w.backoff
+	for i := 1; i < attempt && d < w.maxBackoff; i++ {
+		d *= 2
+	}
+	if w.maxBackoff > 0 && d > w.maxBackoff {
+		d = w.maxBackoff
+	}
+	return d
+}
+
+// retryable reports whether a failed job should be tried again.
+// Validation errors, content violations and API errors other than rate
+// limits and server errors are permanent.
+func retryable(err error) bool {
+	if errors.Is(err, ErrContentViolation) || errors.Is(err, ErrNoParams) {
+		return false
+	}
+	if _, ok := types.AsValidationError(err); ok {
+		return false
+	}
+	var apiErr *transport.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable() || apiErr.IsRateLimit()
+	}
+	return true
+}
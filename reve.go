@@ -5,6 +5,8 @@ package reve
 
 import (
 	"github.com/shamspias/reve-go/image"
+	"github.com/shamspias/reve-go/internal/transport"
+	"github.com/shamspias/reve-go/internal/validator"
 	"github.com/shamspias/reve-go/types"
 )
 
@@ -16,6 +18,12 @@ type (
 	// ModelVersion represents model versions.
 	ModelVersion = types.ModelVersion
 
+	// Endpoint identifies an image API endpoint.
+	Endpoint = types.Endpoint
+
+	// ModelInfo describes a model version in the catalogue.
+	ModelInfo = types.ModelInfo
+
 	// OutputFormat represents response formats.
 	OutputFormat = types.OutputFormat
 
@@ -31,6 +39,12 @@ type (
 	// RawResult represents a raw binary result.
 	RawResult = types.RawResult
 
+	// StreamResult describes an image streamed to an io.Writer.
+	StreamResult = types.StreamResult
+
+	// PromptBuilder builds remix prompts with automatic image indices.
+	PromptBuilder = types.PromptBuilder
+
 	// CreateParams is parameters for image creation.
 	CreateParams = image.CreateParams
 
@@ -48,6 +62,97 @@ type (
 
 	// Cost represents an estimated cost.
 	Cost = image.Cost
+
+	// ValidationError collects every invalid field of a request.
+	ValidationError = types.ValidationError
+
+	// FieldError describes a single invalid field.
+	FieldError = types.FieldError
+
+	// VersionPinner records and pins resolved model versions.
+	VersionPinner = image.VersionPinner
+
+	// DriftEvent reports a change in a resolved model version.
+	DriftEvent = image.DriftEvent
+
+	// CreateBuilder builds create requests fluently.
+	CreateBuilder = image.CreateBuilder
+
+	// EditBuilder builds edit requests fluently.
+	EditBuilder = image.EditBuilder
+
+	// RemixBuilder builds remix requests fluently.
+	RemixBuilder = image.RemixBuilder
+
+	// Provenance describes how an image was generated.
+	Provenance = types.Provenance
+
+	// SaveOption configures Result.SaveTo and RawResult.SaveTo.
+	SaveOption = types.SaveOption
+
+	// Job is a handle to an async image operation.
+	Job = image.Job
+
+	// JobStatus is the state of an async job.
+	JobStatus = image.JobStatus
+
+	// RequestOption overrides client defaults for a single call.
+	RequestOption = image.RequestOption
+
+	// RequestConfig holds the settings a RequestOption can change.
+	RequestConfig = image.RequestConfig
+
+	// RetryPolicy configures retries for a single call.
+	RetryPolicy = image.RetryPolicy
+
+	// CredentialsProvider supplies the API key per request.
+	CredentialsProvider = transport.CredentialsProvider
+
+	// CredentialsRefresher is a provider that can reload its key.
+	CredentialsRefresher = transport.CredentialsRefresher
+
+	// FileCredentials reads the API key from a file, reloading it on change.
+	FileCredentials = transport.FileCredentials
+
+	// KeyPool spreads requests across several API keys.
+	KeyPool = transport.KeyPool
+
+	// PoolKey is one labelled API key in a KeyPool.
+	PoolKey = transport.PoolKey
+
+	// KeyPoolConfig configures a KeyPool.
+	KeyPoolConfig = transport.KeyPoolConfig
+
+	// KeyStrategy selects the next key from a KeyPool.
+	KeyStrategy = transport.KeyStrategy
+
+	// KeyStats reports the state of one key in a KeyPool.
+	KeyStats = transport.KeyStats
+
+	// ProxyPoolConfig configures the proxy pool set by WithProxyPool.
+	ProxyPoolConfig = transport.ProxyPoolConfig
+
+	// ProxyStrategy selects the proxy for each request.
+	ProxyStrategy = transport.ProxyStrategy
+
+	// ProxyStats reports the state of one proxy in a pool.
+	ProxyStats = transport.ProxyStats
+
+	// BaseURLStats reports the state of one base URL set by WithBaseURLs.
+	BaseURLStats = transport.BaseURLStats
+)
+
+// Key selection strategies.
+const (
+	RoundRobin  = transport.RoundRobin
+	LeastUsed   = transport.LeastUsed
+	MostCredits = transport.MostCredits
+)
+
+// Proxy selection strategies.
+const (
+	ProxyRoundRobin = transport.ProxyRoundRobin
+	ProxyRandom     = transport.ProxyRandom
 )
 
 // Aspect ratio constants.
@@ -73,6 +178,28 @@ const (
 	VersionRemixFast20251030 = types.VersionRemixFast20251030
 )
 
+// Endpoint constants.
+const (
+	EndpointCreate = types.EndpointCreate
+	EndpointEdit   = types.EndpointEdit
+	EndpointRemix  = types.EndpointRemix
+)
+
+// Version pin modes.
+const (
+	PinRecord  = image.PinRecord
+	PinEnforce = image.PinEnforce
+)
+
+// Job states.
+const (
+	JobQueued    = image.JobQueued
+	JobRunning   = image.JobRunning
+	JobSucceeded = image.JobSucceeded
+	JobFailed    = image.JobFailed
+	JobCanceled  = image.JobCanceled
+)
+
 // Output format constants.
 const (
 	FormatJSON = types.FormatJSON
@@ -81,6 +208,21 @@ const (
 	FormatWebP = types.FormatWebP
 )
 
+// Validation errors, matched with errors.Is against a ValidationError.
+var (
+	ErrEmptyPrompt            = validator.ErrEmptyPrompt
+	ErrPromptTooLong          = validator.ErrPromptTooLong
+	ErrEmptyInstruction       = validator.ErrEmptyInstruction
+	ErrEmptyReferenceImage    = validator.ErrEmptyReferenceImage
+	ErrNoReferenceImages      = validator.ErrNoReferenceImages
+	ErrTooManyReferenceImages = validator.ErrTooManyReferenceImages
+	ErrInvalidAspectRatio     = validator.ErrInvalidAspectRatio
+	ErrInvalidUpscaleFactor   = validator.ErrInvalidUpscaleFactor
+	ErrInvalidScaling         = validator.ErrInvalidScaling
+	ErrRefOutOfBounds         = validator.ErrRefOutOfBounds
+	ErrVersionMismatch        = validator.ErrVersionMismatch
+)
+
 // Helper functions re-exported for convenience.
 var (
 	// NewImage creates an Image from bytes.
@@ -95,15 +237,75 @@ var (
 	// Ref creates an image reference tag.
 	Ref = types.Ref
 
+	// ParseRefs extracts image reference indices from a prompt.
+	ParseRefs = types.ParseRefs
+
+	// NewPromptBuilder creates a remix prompt builder.
+	NewPromptBuilder = types.NewPromptBuilder
+
 	// Upscale creates an upscale operation.
 	Upscale = types.Upscale
 
 	// RemoveBackground creates a background removal operation.
 	RemoveBackground = types.RemoveBackground
 
+	// Versions lists the model versions accepted by an endpoint.
+	Versions = types.Versions
+
+	// LookupVersion returns catalogue information for a version.
+	LookupVersion = types.LookupVersion
+
 	// DetectFormat detects format from file path.
 	DetectFormat = types.DetectFormat
 
+	// WithProvenance embeds provenance metadata when saving.
+	WithProvenance = types.WithProvenance
+
+	// WithSidecar also writes provenance to a JSON sidecar file.
+	WithSidecar = types.WithSidecar
+
+	// WithRequestTimeout sets the timeout for one request.
+	WithRequestTimeout = image.WithRequestTimeout
+
+	// WithRequestRetry sets the retry behavior for one request.
+	WithRequestRetry = image.WithRequestRetry
+
+	// WithHeader sets an extra HTTP header on one request.
+	WithHeader = image.WithHeader
+
+	// WithIdempotencyKey sends an Idempotency-Key header.
+	WithIdempotencyKey = image.WithIdempotencyKey
+
+	// WithBreadcrumb sets the tracking ID for one request.
+	WithBreadcrumb = image.WithBreadcrumb
+
+	// WithAccept sets the Accept header for one request.
+	WithAccept = image.WithAccept
+
+	// WithRequestMaxResponseSize limits the response size of one request.
+	WithRequestMaxResponseSize = image.WithRequestMaxResponseSize
+
+	// EmbedProvenance embeds provenance metadata in image bytes.
+	EmbedProvenance = types.EmbedProvenance
+
+	// ReadProvenance extracts embedded provenance metadata.
+	ReadProvenance = types.ReadProvenance
+
+	// ReadProvenanceFile reads provenance from a file or its sidecar.
+	ReadProvenanceFile = types.ReadProvenanceFile
+
+	// NewCreate starts a fluent create request.
+	NewCreate = image.NewCreate
+
+	// NewEdit starts a fluent edit request.
+	NewEdit = image.NewEdit
+
+	// NewRemix starts a fluent remix request.
+	NewRemix = image.NewRemix
+
+	// NewVersionPinner creates a model version pinner.
+	NewVersionPinner = image.NewVersionPinner
+
 	// EstimateCreate estimates create cost.
 	EstimateCreate = image.EstimateCreate
 
@@ -131,3 +333,40 @@ var (
 	// Errors returns all errors from batch.
 	Errors = image.Errors
 )
+
+// Async errors.
+var (
+	ErrQueueFull  = image.ErrQueueFull
+	ErrPoolClosed = image.ErrPoolClosed
+)
+
+// Credential providers.
+var (
+	// ErrNoCredentials is returned when no provider has an API key.
+	ErrNoCredentials = transport.ErrNoCredentials
+
+	// StaticCredentials returns a provider for a fixed key.
+	StaticCredentials = transport.StaticCredentials
+
+	// EnvCredentials reads the key from an environment variable.
+	EnvCredentials = transport.EnvCredentials
+
+	// NewFileCredentials reads the key from a file, reloading it on change.
+	NewFileCredentials = transport.NewFileCredentials
+
+	// ChainCredentials uses the first provider that has a key.
+	ChainCredentials = transport.ChainCredentials
+
+	// NewKeyPool creates a pool of API keys.
+	NewKeyPool = transport.NewKeyPool
+
+	// ErrEmptyKeyPool is returned by a key pool with no keys.
+	ErrEmptyKeyPool = transport.ErrEmptyKeyPool
+
+	// ErrEmptyProxyPool is returned when a proxy pool has no proxies.
+	ErrEmptyProxyPool = transport.ErrEmptyProxyPool
+
+	// ErrResponseTooLarge is returned when a response exceeds the maximum
+	// response size.
+	ErrResponseTooLarge = transport.ErrResponseTooLarge
+)
@@ -0,0 +1,423 @@
+// Package cassette provides a record/replay http.RoundTripper for
+// deterministic integration tests.
+//
+// In record mode requests go to the real API and each request/response
+// pair is saved to a cassette file with credential headers scrubbed. In
+// replay mode responses are served from the cassette, matching requests
+// on method, path and a normalised JSON body.
+//
+// # Usage
+//
+//	func TestCreateIntegration(t *testing.T) {
+//		mode := cassette.ModeReplay
+//		if os.Getenv("REVE_RECORD") != "" {
+//			mode = cassette.ModeRecord
+//		}
+//		rec, err := cassette.New("testdata/create.json", mode,
+//			cassette.WithTest(t),
+//			cassette.WithTruncation(256),
+//		)
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//
+//		client := reve.NewClient(os.Getenv("REVE_API_KEY"), reve.WithTransport(rec))
+//		// ...
+//	}
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+)
+
+// Mode selects between recording and replaying.
+type Mode int
+
+// Recorder modes.
+const (
+	// ModeReplay serves responses from the cassette file.
+	ModeReplay Mode = iota
+
+	// ModeRecord forwards requests and saves them to the cassette file.
+	ModeRecord
+)
+
+// ErrNoMatch is returned in replay mode when no recorded interaction
+// matches a request.
+var ErrNoMatch = errors.New("cassette: no matching interaction")
+
+// DefaultRedactedHeaders are never written to a cassette.
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"X-Api-Key",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// Cassette is the on-disk format.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request/response pair.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request.
+type Request struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+
+	// Encoding is "base64" for binary bodies.
+	Encoding string `json:"encoding,omitempty"`
+
+	// Truncated is true if the body was shortened when recording.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Option configures a Recorder.
+type Option func(*Recorder)
+
+// WithTransport sets the transport used in record mode.
+// Default: http.DefaultTransport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = rt
+	}
+}
+
+// WithTest fails t on unmatched requests and saves the cassette when
+// the test finishes.
+func WithTest(t testing.TB) Option {
+	return func(r *Recorder) {
+		r.t = t
+	}
+}
+
+// WithRedactedHeaders also keeps the named request and response
+// headers out of the cassette, in addition to DefaultRedactedHeaders.
+func WithRedactedHeaders(names ...string) Option {
+	return func(r *Recorder) {
+		r.redact = append(r.redact, names...)
+	}
+}
+
+// WithTruncation shortens payloads longer than maxLen when recording.
+// JSON strings such as base64 images are replaced by a digest, which
+// still matches in replay, and binary bodies are cut to maxLen bytes.
+// Replayed images are therefore not decodable.
+func WithTruncation(maxLen int) Option {
+	return func(r *Recorder) {
+		r.truncate = maxLen
+	}
+}
+
+// Recorder is a record/replay http.RoundTripper.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+	t         testing.TB
+	truncate  int
+	redact    []string
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// New creates a recorder for the cassette at path. In replay mode the
+// file must exist.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+		redact:    append([]string(nil), DefaultRedactedHeaders...),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("cassette: parse %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+
+	if r.t != nil {
+		r.t.Cleanup(func() {
+			if err := r.Stop(); err != nil {
+				r.t.Error(err)
+			}
+		})
+	}
+	return r, nil
+}
+
+// Mode returns the recorder mode.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Interactions returns the recorded interactions.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.cassette.Interactions...)
+}
+
+// Stop saves the cassette in record mode. It is a no-op in replay mode.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+
+	if dir := filepath.Dir(r.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("cassette: %w", err)
+		}
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// RoundTrip implements http.RoundTripper. req is not modified; its body
+// is read and closed, and a clone is sent in record mode.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	clone, body, err := cloneRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.mode == ModeRecord {
+		return r.record(clone, body)
+	}
+	return r.replay(req, body)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	in := Interaction{
+		Request: Request{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  req.URL.RawQuery,
+			Header: r.scrub(req.Header),
+			Body:   string(r.normalize(body)),
+		},
+		Response: r.encodeResponse(resp, respBody),
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	key := string(r.normalize(body))
+
+	r.mu.Lock()
+	match := -1
+	for i, in := range r.cassette.Interactions {
+		if in.Request.Method != req.Method || in.Request.Path != req.URL.Path || in.Request.Body != key {
+			continue
+		}
+		if !r.used[i] {
+			match = i
+			break
+		}
+		if match < 0 {
+			match = i
+		}
+	}
+	if match >= 0 {
+		r.used[match] = true
+	}
+	r.mu.Unlock()
+
+	if match < 0 {
+		err := fmt.Errorf("%w: %s %s", ErrNoMatch, req.Method, req.URL.Path)
+		if r.t != nil {
+			r.t.Errorf("%v\nbody: %s", err, preview(key))
+		}
+		return nil, err
+	}
+	return decodeResponse(req, r.cassette.Interactions[match].Response)
+}
+
+func (r *Recorder) encodeResponse(resp *http.Response, body []byte) Response {
+	out := Response{Status: resp.StatusCode, Header: r.scrub(resp.Header)}
+	if isJSON(resp.Header.Get("Content-Type")) && json.Valid(body) {
+		out.Body = string(r.normalize(body))
+		return out
+	}
+	if r.truncate > 0 && len(body) > r.truncate {
+		body = body[:r.truncate]
+		out.Truncated = true
+	}
+	if utf8.Valid(body) {
+		out.Body = string(body)
+	} else {
+		out.Body = base64.StdEncoding.EncodeToString(body)
+		out.Encoding = "base64"
+	}
+	return out
+}
+
+func decodeResponse(req *http.Request, rec Response) (*http.Response, error) {
+	body := []byte(rec.Body)
+	if rec.Encoding == "base64" {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(rec.Body); err != nil {
+			return nil, fmt.Errorf("cassette: decode body: %w", err)
+		}
+	}
+	header := rec.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Del("Content-Length")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.Status, http.StatusText(rec.Status)),
+		StatusCode:    rec.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// normalize re-encodes JSON bodies with sorted keys and, when
+// truncation is enabled, replaces long strings with their digest.
+// Other bodies are returned unchanged.
+func (r *Recorder) normalize(body []byte) []byte {
+	if len(body) == 0 {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body
+	}
+	if r.truncate > 0 {
+		v = truncateStrings(v, r.truncate)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+func truncateStrings(v any, maxLen int) any {
+	switch t := v.(type) {
+	case string:
+		if len(t) <= maxLen {
+			return t
+		}
+		sum := sha256.Sum256([]byte(t))
+		return fmt.Sprintf("<truncated %d bytes sha256:%s>", len(t), hex.EncodeToString(sum[:8]))
+	case []any:
+		for i := range t {
+			t[i] = truncateStrings(t[i], maxLen)
+		}
+	case map[string]any:
+		for k := range t {
+			t[k] = truncateStrings(t[k], maxLen)
+		}
+	}
+	return v
+}
+
+// cloneRequest reads and closes the body of req and returns a clone
+// that can send it again, with the body.
+func cloneRequest(req *http.Request) (*http.Request, []byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	clone := req.Clone(req.Context())
+	clone.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	clone.Body, _ = clone.GetBody()
+	return clone, body, nil
+}
+
+func (r *Recorder) scrub(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range r.redact {
+		out.Del(name)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func isJSON(contentType string) bool {
+	return strings.HasPrefix(contentType, "application/json")
+}
+
+func preview(s string) string {
+	const maxPreview = 200
+	if len(s) > maxPreview {
+		return s[:maxPreview] + "..."
+	}
+	return s
+}
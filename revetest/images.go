@@ -0,0 +1,148 @@
+package revetest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/shamspias/reve-go/types"
+)
+
+// baseUnit is the number of pixels per aspect ratio unit.
+const baseUnit = 16
+
+// Generate returns a deterministic image for seed in the given format.
+// PNG and JPEG images are gradients derived from the seed. WebP images
+// are a single solid color, since the standard library has no WebP
+// encoder.
+//
+// Example:
+//
+//	data, err := revetest.Generate("a red apple", types.Ratio1x1, types.FormatPNG)
+func Generate(seed string, ratio types.AspectRatio, format types.OutputFormat) ([]byte, error) {
+	return generate(seed, ratio, 1, format)
+}
+
+func generate(seed string, ratio types.AspectRatio, scale int, format types.OutputFormat) ([]byte, error) {
+	w, h := ratio.Dimensions()
+	if w == 0 {
+		w, h = 3, 2
+	}
+	if scale < 1 {
+		scale = 1
+	}
+	w, h = w*baseUnit*scale, h*baseUnit*scale
+
+	sum := sha256.Sum256([]byte(seed))
+	from := color.RGBA{R: sum[0], G: sum[1], B: sum[2], A: 255}
+	to := color.RGBA{R: sum[3], G: sum[4], B: sum[5], A: 255}
+
+	var buf bytes.Buffer
+	switch format {
+	case types.FormatWebP:
+		return encodeSolidWebP(w, h, from), nil
+	case types.FormatJPEG:
+		if err := jpeg.Encode(&buf, gradient(w, h, from, to), &jpeg.Options{Quality: 90}); err != nil {
+			return nil, err
+		}
+	default:
+		if err := png.Encode(&buf, gradient(w, h, from, to)); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func gradient(w, h int, from, to color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	span := w + h - 2
+	if span < 1 {
+		span = 1
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			t := x + y
+			img.SetRGBA(x, y, color.RGBA{
+				R: lerp(from.R, to.R, t, span),
+				G: lerp(from.G, to.G, t, span),
+				B: lerp(from.B, to.B, t, span),
+				A: 255,
+			})
+		}
+	}
+	return img
+}
+
+func lerp(a, b uint8, t, span int) uint8 {
+	return uint8((int(a)*(span-t) + int(b)*t) / span)
+}
+
+// encodeSolidWebP encodes a lossless (VP8L) WebP image of one color.
+// Every prefix code has a single symbol, so pixels take zero bits.
+func encodeSolidWebP(w, h int, c color.RGBA) []byte {
+	var bw bitWriter
+	bw.write(0x2f, 8) // VP8L signature
+	bw.write(uint32(w-1), 14)
+	bw.write(uint32(h-1), 14)
+	bw.write(0, 1) // alpha is not used
+	bw.write(0, 3) // version
+	bw.write(0, 1) // no transforms
+	bw.write(0, 1) // no color cache
+	bw.write(0, 1) // no meta prefix codes
+
+	// Green, red, blue and alpha: simple codes with one 8-bit symbol.
+	for _, v := range []uint8{c.G, c.R, c.B, 255} {
+		bw.write(1, 1) // simple code
+		bw.write(0, 1) // one symbol
+		bw.write(1, 1) // 8-bit symbol
+		bw.write(uint32(v), 8)
+	}
+	// Distance: simple code with the 1-bit symbol 0.
+	bw.write(1, 1)
+	bw.write(0, 1)
+	bw.write(0, 1)
+	bw.write(0, 1)
+
+	payload := bw.bytes()
+	pad := len(payload) % 2
+
+	out := make([]byte, 0, 20+len(payload)+pad)
+	out = append(out, "RIFF"...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(4+8+len(payload)+pad))
+	out = append(out, "WEBPVP8L"...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(payload)))
+	out = append(out, payload...)
+	if pad == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+// bitWriter writes bits least significant first, as VP8L requires.
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+func (b *bitWriter) write(v uint32, n uint) {
+	b.acc |= uint64(v) << b.nbits
+	b.nbits += n
+	for b.nbits >= 8 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc >>= 8
+		b.nbits -= 8
+	}
+}
+
+func (b *bitWriter) bytes() []byte {
+	if b.nbits > 0 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc, b.nbits = 0, 0
+	}
+	return b.buf
+}
@@ -0,0 +1,512 @@
+// Package revetest provides an in-process fake Reve API server for tests.
+//
+// The server implements /v1/image/create, /v1/image/edit and
+// /v1/image/remix. It validates request bodies with the SDK's own
+// rules, returns deterministic images, sets the X-Reve-* headers and
+// tracks a credit balance. Failures can be scripted and every request
+// is recorded for assertions.
+//
+// # Usage
+//
+//	func TestGenerate(t *testing.T) {
+//		srv := revetest.NewServer(t, revetest.WithCredits(100))
+//		client := srv.Client()
+//
+//		srv.FailNext(revetest.RateLimited(time.Second))
+//
+//		result, err := client.Images.Create(ctx, &image.CreateParams{Prompt: "a cat"})
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		if got := len(srv.Requests()); got != 2 {
+//			t.Errorf("requests = %d, want 2", got)
+//		}
+//	}
+package revetest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	reve "github.com/shamspias/reve-go"
+	"github.com/shamspias/reve-go/image"
+	"github.com/shamspias/reve-go/types"
+)
+
+// Error codes returned by the fake server.
+const (
+	CodeMissingParam      = "MISSING_REQUIRED_PARAMETER"
+	CodeInvalidParam      = "INVALID_PARAMETER_VALUE"
+	CodePromptTooLong     = "PROMPT_TOO_LONG"
+	CodeContentViolation  = "CONTENT_POLICY_VIOLATION"
+	CodeIndexOutOfBounds  = "INDEX_OUT_OF_BOUNDS"
+	CodeInvalidAPIKey     = "INVALID_API_KEY"
+	CodeInsufficientFunds = "INSUFFICIENT_CREDITS"
+	CodeRateLimit         = "RATE_LIMIT_EXCEEDED"
+	CodeInternal          = "INTERNAL_ERROR"
+)
+
+// DefaultCredits is the starting credit balance.
+const DefaultCredits = 10000
+
+// Failure is a scripted error response.
+type Failure struct {
+	// Status is the HTTP status code.
+	Status int
+
+	// Code is the error_code in the response body.
+	Code string
+
+	// Message is the error message.
+	Message string
+
+	// RetryAfter sets the Retry-After header when positive.
+	RetryAfter time.Duration
+}
+
+// RateLimited returns a 429 failure with a Retry-After header.
+func RateLimited(retryAfter time.Duration) Failure {
+	return Failure{Status: http.StatusTooManyRequests, Code: CodeRateLimit, Message: "Rate limit exceeded", RetryAfter: retryAfter}
+}
+
+// InsufficientCredits returns a 402 failure.
+func InsufficientCredits() Failure {
+	return Failure{Status: http.StatusPaymentRequired, Code: CodeInsufficientFunds, Message: "Insufficient credits"}
+}
+
+// ContentViolation returns a content policy failure.
+func ContentViolation() Failure {
+	return Failure{Status: http.StatusBadRequest, Code: CodeContentViolation, Message: "Content policy violation"}
+}
+
+// ServerError returns a 5xx failure with the given status.
+func ServerError(status int) Failure {
+	return Failure{Status: status, Code: CodeInternal, Message: http.StatusText(status)}
+}
+
+// Request is a recorded request.
+type Request struct {
+	Method     string
+	Path       string
+	Endpoint   types.Endpoint
+	Breadcrumb string
+	Header     http.Header
+	Body       []byte
+
+	// Exactly one of these is set for a well-formed image request.
+	Create *image.CreateParams
+	Edit   *image.EditParams
+	Remix  *image.RemixParams
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithCredits sets the starting credit balance.
+func WithCredits(n int) Option {
+	return func(s *Server) {
+		s.credits = n
+	}
+}
+
+// WithAPIKey requires requests to use the given API key.
+// By default any non-empty key is accepted.
+func WithAPIKey(key string) Option {
+	return func(s *Server) {
+		s.apiKey = key
+	}
+}
+
+// WithVersion sets the version that "latest" resolves to for an endpoint.
+func WithVersion(endpoint types.Endpoint, version types.ModelVersion) Option {
+	return func(s *Server) {
+		s.latest[endpoint] = version
+	}
+}
+
+// WithDeprecated sends a Deprecation header with results served by any
+// of versions, as the API does for models scheduled for removal.
+func WithDeprecated(versions ...types.ModelVersion) Option {
+	return func(s *Server) {
+		s.deprecated = append(s.deprecated, versions...)
+	}
+}
+
+// WithContentViolations flags results whose prompt contains any of words
+// with content_violation, as the API does for borderline content.
+func WithContentViolations(words ...string) Option {
+	return func(s *Server) {
+		s.violations = append(s.violations, words...)
+	}
+}
+
+// Server is a fake Reve API server.
+type Server struct {
+	// URL is the base URL of the server.
+	URL string
+
+	srv        *httptest.Server
+	mu         sync.Mutex
+	credits    int
+	apiKey     string
+	latest     map[types.Endpoint]types.ModelVersion
+	violations []string
+	deprecated []types.ModelVersion
+	failures   []Failure
+	requests   []Request
+	seq        int
+}
+
+// NewServer starts a fake server that is closed when the test ends.
+func NewServer(t testing.TB, opts ...Option) *Server {
+	t.Helper()
+	s := NewUnstartedServer(opts...)
+	s.Start()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// NewUnstartedServer returns a server that is not yet listening.
+// Call Start to start it and Close to stop it.
+func NewUnstartedServer(opts ...Option) *Server {
+	s := &Server{
+		credits: DefaultCredits,
+		latest: map[types.Endpoint]types.ModelVersion{
+			types.EndpointCreate: types.VersionCreate20250915,
+			types.EndpointEdit:   types.VersionEdit20250915,
+			types.EndpointRemix:  types.VersionRemix20250915,
+		},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Start starts the server.
+func (s *Server) Start() {
+	s.srv = httptest.NewServer(s)
+	s.URL = s.srv.URL
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	if s.srv != nil {
+		s.srv.Close()
+	}
+}
+
+// Client returns a reve client pointed at the server. Retries use
+// millisecond waits so scripted failures don't slow tests down.
+func (s *Server) Client(opts ...reve.Option) *reve.Client {
+	base := []reve.Option{
+		reve.WithBaseURL(s.URL),
+		reve.WithRetry(reve.DefaultMaxRetries, time.Millisecond, 10*time.Millisecond),
+	}
+	return reve.NewClient("revetest-key", append(base, opts...)...)
+}
+
+// FailNext queues failures for the next requests, one per request.
+// Only authorized POST requests to an image endpoint use them; a stray
+// request to another route or with a bad API key leaves the queue as it
+// is.
+func (s *Server) FailNext(failures ...Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, failures...)
+}
+
+// SetCredits sets the credit balance.
+func (s *Server) SetCredits(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.credits = n
+}
+
+// Credits returns the credit balance.
+func (s *Server) Credits() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.credits
+}
+
+// Requests returns all recorded requests.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// LastRequest returns the most recent request.
+func (s *Server) LastRequest() (Request, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.requests) == 0 {
+		return Request{}, false
+	}
+	return s.requests[len(s.requests)-1], true
+}
+
+// Reset clears recorded requests and pending failures.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+	s.failures = nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rec := Request{
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Endpoint:   types.Endpoint(strings.TrimPrefix(r.URL.Path, "/v1/image/")),
+		Breadcrumb: r.URL.Query().Get("breadcrumb"),
+		Header:     r.Header.Clone(),
+		Body:       body,
+	}
+
+	s.mu.Lock()
+	s.seq++
+	requestID := fmt.Sprintf("revetest-%06d", s.seq)
+	s.mu.Unlock()
+
+	w.Header().Set("X-Reve-Request-Id", requestID)
+
+	params, err := decodeParams(&rec, body)
+	s.record(rec)
+
+	var failure Failure
+	switch {
+	case r.Method != http.MethodPost:
+		writeError(w, Failure{Status: http.StatusMethodNotAllowed, Code: CodeInvalidParam, Message: "Method not allowed"})
+	case params == nil && err == nil:
+		writeError(w, Failure{Status: http.StatusNotFound, Code: CodeInvalidParam, Message: "Unknown endpoint " + r.URL.Path})
+	case !s.authorized(r):
+		writeError(w, Failure{Status: http.StatusUnauthorized, Code: CodeInvalidAPIKey, Message: "Invalid API key"})
+	case s.nextFailure(&failure):
+		writeError(w, failure)
+	case err != nil:
+		writeError(w, Failure{Status: http.StatusBadRequest, Code: CodeInvalidParam, Message: err.Error()})
+	default:
+		s.generate(w, r, rec.Endpoint, params, requestID)
+	}
+}
+
+// nextFailure pops the next scripted failure into f. It reports false
+// if none is queued.
+func (s *Server) nextFailure(f *Failure) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.failures) == 0 {
+		return false
+	}
+	*f = s.failures[0]
+	s.failures = s.failures[1:]
+	return true
+}
+
+func (s *Server) record(rec Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, rec)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	key := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if key == "" || key == r.Header.Get("Authorization") {
+		return false
+	}
+	return s.apiKey == "" || key == s.apiKey
+}
+
+// request is the common view of the three parameter types.
+type request struct {
+	seed        string
+	ratio       types.AspectRatio
+	version     types.ModelVersion
+	postprocess []types.Postprocess
+	scaling     float64
+	validate    func() error
+}
+
+func decodeParams(rec *Request, body []byte) (*request, error) {
+	switch rec.Endpoint {
+	case types.EndpointCreate:
+		var p image.CreateParams
+		if err := json.Unmarshal(body, &p); err != nil {
+			return &request{}, err
+		}
+		rec.Create = &p
+		return &request{p.Prompt, p.AspectRatio, p.Version, p.Postprocess, p.TestTimeScaling, p.Validate}, nil
+	case types.EndpointEdit:
+		var p image.EditParams
+		if err := json.Unmarshal(body, &p); err != nil {
+			return &request{}, err
+		}
+		rec.Edit = &p
+		return &request{p.Instruction + p.ReferenceImage, p.AspectRatio, p.Version, p.Postprocess, p.TestTimeScaling, p.Validate}, nil
+	case types.EndpointRemix:
+		var p image.RemixParams
+		if err := json.Unmarshal(body, &p); err != nil {
+			return &request{}, err
+		}
+		rec.Remix = &p
+		return &request{p.Prompt + strings.Join(p.ReferenceImages, ""), p.AspectRatio, p.Version, p.Postprocess, p.TestTimeScaling, p.Validate}, nil
+	}
+	return nil, nil
+}
+
+func (s *Server) generate(w http.ResponseWriter, r *http.Request, endpoint types.Endpoint, req *request, requestID string) {
+	if err := req.validate(); err != nil {
+		writeError(w, validationFailure(err))
+		return
+	}
+
+	version := s.resolve(endpoint, req.version)
+	cost := estimate(endpoint, version, req.scaling, req.postprocess)
+
+	s.mu.Lock()
+	if s.credits < cost {
+		s.mu.Unlock()
+		writeError(w, InsufficientCredits())
+		return
+	}
+	s.credits -= cost
+	remaining := s.credits
+	s.mu.Unlock()
+
+	scale := 1
+	for _, pp := range req.postprocess {
+		if pp.Process == types.ProcessUpscale {
+			scale *= pp.UpscaleFactor
+		}
+	}
+
+	accept := types.OutputFormat(r.Header.Get("Accept"))
+	format := accept
+	if format != types.FormatJPEG && format != types.FormatWebP {
+		format = types.FormatPNG
+	}
+	data, err := generate(req.seed, req.ratio, scale, format)
+	if err != nil {
+		writeError(w, ServerError(http.StatusInternalServerError))
+		return
+	}
+
+	violation := s.violates(req.seed)
+	h := w.Header()
+	h.Set("X-Reve-Version", string(version))
+	h.Set("X-Reve-Content-Violation", strconv.FormatBool(violation))
+	h.Set("X-Reve-Credits-Used", strconv.Itoa(cost))
+	h.Set("X-Reve-Credits-Remaining", strconv.Itoa(remaining))
+	if slices.Contains(s.deprecated, version) {
+		h.Set("Deprecation", "true")
+	}
+
+	if accept == types.FormatPNG || accept == types.FormatJPEG || accept == types.FormatWebP {
+		h.Set("Content-Type", string(format))
+		_, _ = w.Write(data)
+		return
+	}
+
+	h.Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(types.Result{
+		Image:            base64.StdEncoding.EncodeToString(data),
+		Version:          string(version),
+		ContentViolation: violation,
+		RequestID:        requestID,
+		CreditsUsed:      cost,
+		CreditsRemaining: remaining,
+	})
+}
+
+func (s *Server) resolve(endpoint types.Endpoint, v types.ModelVersion) types.ModelVersion {
+	switch v {
+	case "", types.VersionLatest:
+		return s.latest[endpoint]
+	case types.VersionLatestFast:
+		for _, info := range types.Versions(endpoint) {
+			if info.Fast && !info.Alias {
+				return info.Version
+			}
+		}
+		return s.latest[endpoint]
+	}
+	return v
+}
+
+func (s *Server) violates(seed string) bool {
+	lower := strings.ToLower(seed)
+	for _, word := range s.violations {
+		if strings.Contains(lower, strings.ToLower(word)) {
+			return true
+		}
+	}
+	return false
+}
+
+func estimate(endpoint types.Endpoint, version types.ModelVersion, scaling float64, pp []types.Postprocess) int {
+	switch endpoint {
+	case types.EndpointEdit:
+		return image.EstimateEdit(version.IsFast(), scaling, pp).TotalCredits
+	case types.EndpointRemix:
+		return image.EstimateRemix(version.IsFast(), scaling, pp).TotalCredits
+	default:
+		return image.EstimateCreate(scaling, pp).TotalCredits
+	}
+}
+
+// validationFailure maps a validation error to the API's error response.
+func validationFailure(err error) Failure {
+	f := Failure{Status: http.StatusBadRequest, Code: CodeInvalidParam, Message: err.Error()}
+	var verr *types.ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) == 0 {
+		return f
+	}
+	fe := verr.Errors[0]
+	f.Message = fe.Error()
+	switch fe.Code {
+	case types.CodeRequired:
+		f.Code = CodeMissingParam
+	case types.CodeTooLong:
+		f.Code = CodePromptTooLong
+	case types.CodeIndexOutOfBounds:
+		f.Code = CodeIndexOutOfBounds
+	}
+	return f
+}
+
+func writeError(w http.ResponseWriter, f Failure) {
+	if f.Status == 0 {
+		f.Status = http.StatusInternalServerError
+	}
+	if f.Message == "" {
+		f.Message = http.StatusText(f.Status)
+	}
+	h := w.Header()
+	h.Set("Content-Type", "application/json")
+	if f.Code != "" {
+		h.Set("X-Reve-Error-Code", f.Code)
+	}
+	if f.RetryAfter > 0 {
+		h.Set("Retry-After", strconv.Itoa(int((f.RetryAfter+time.Second-1)/time.Second)))
+	}
+	w.WriteHeader(f.Status)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"error_code": f.Code,
+		"message":    f.Message,
+	})
+}
@@ -0,0 +1,160 @@
+package reve
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/shamspias/reve-go/internal/transport"
+)
+
+// ErrTLSConfig is returned by every request of a client built by
+// NewClient with invalid TLS settings. The settings error is wrapped.
+var ErrTLSConfig = errors.New("reve: invalid TLS settings; request refused")
+
+// WithTLSConfig sets the base TLS configuration. It applies to the
+// default transport and to the HTTP, SOCKS5 and environment proxy
+// transports, whatever the order of the options. cfg is copied.
+//
+// Example:
+//
+//	client := reve.NewClient(apiKey, reve.WithTLSConfig(&tls.Config{
+//		MinVersion: tls.VersionTLS13,
+//	}))
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *Config) {
+		if cfg == nil {
+			c.TLSConfig = nil
+			return
+		}
+		c.TLSConfig = cfg.Clone()
+	}
+}
+
+// WithRootCAs trusts the certificates in the given PEM files in addition
+// to the system roots, for example the CA of a TLS-intercepting gateway.
+//
+// Example:
+//
+//	client, err := reve.New(apiKey, reve.WithRootCAs("/etc/ssl/corp-ca.pem"))
+func WithRootCAs(pemFiles ...string) Option {
+	return func(c *Config) {
+		pool, err := transport.LoadRootCAs(pemFiles...)
+		if err != nil {
+			c.failTLS(fmt.Errorf("reve: WithRootCAs: %w", err))
+			return
+		}
+		c.tlsConfig().RootCAs = pool
+	}
+}
+
+// WithClientCertificate presents a client certificate for mutual TLS.
+// certFile and keyFile are PEM files.
+//
+// Example:
+//
+//	client, err := reve.New(apiKey,
+//		reve.WithClientCertificate("client.crt", "client.key"),
+//	)
+func WithClientCertificate(certFile, keyFile string) Option {
+	return func(c *Config) {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			c.failTLS(fmt.Errorf("reve: WithClientCertificate: %w", err))
+			return
+		}
+		cfg := c.tlsConfig()
+		cfg.Certificates = append(cfg.Certificates, cert)
+	}
+}
+
+// WithSPKIPins requires the server to present a certificate whose public
+// key matches one of pins. Pins are base64 SHA-256 digests of the
+// SubjectPublicKeyInfo, optionally prefixed with "sha256/". Normal
+// certificate verification still applies.
+//
+// Example:
+//
+//	client, err := reve.New(apiKey, reve.WithSPKIPins(
+//		"sha256/47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+//	))
+func WithSPKIPins(pins ...string) Option {
+	return func(c *Config) {
+		for _, pin := range pins {
+			if _, err := transport.ParsePin(pin); err != nil {
+				c.failTLS(fmt.Errorf("reve: WithSPKIPins: %w", err))
+				return
+			}
+		}
+		c.TLSPins = append(c.TLSPins, pins...)
+	}
+}
+
+// tlsConfig returns c.TLSConfig, creating it if needed.
+func (c *Config) tlsConfig() *tls.Config {
+	if c.TLSConfig == nil {
+		c.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return c.TLSConfig
+}
+
+// applyTLS installs the TLS settings on the transport, creating the
+// default transport if none is set.
+func (c *Config) applyTLS() error {
+	if c.TLSConfig == nil && len(c.TLSPins) == 0 {
+		return nil
+	}
+
+	cfg := c.tlsConfig().Clone()
+	if cfg.MinVersion == 0 {
+		cfg.MinVersion = tls.VersionTLS12
+	}
+	if len(c.TLSPins) > 0 {
+		sums := make([][]byte, 0, len(c.TLSPins))
+		for _, pin := range c.TLSPins {
+			sum, err := transport.ParsePin(pin)
+			if err != nil {
+				return fmt.Errorf("reve: TLS: %w", err)
+			}
+			sums = append(sums, sum)
+		}
+		verifyPins := transport.VerifyPins(sums)
+		if prev := cfg.VerifyConnection; prev != nil {
+			cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+				if err := prev(cs); err != nil {
+					return err
+				}
+				return verifyPins(cs)
+			}
+		} else {
+			cfg.VerifyConnection = verifyPins
+		}
+	}
+
+	rt := c.Transport
+	if rt == nil {
+		rt = transport.CreateDefaultTransport(c.dialOptions())
+	}
+	rt, ok := transport.ApplyTLS(rt, cfg)
+	if !ok {
+		return errors.New("reve: TLS settings need an *http.Transport; configure TLS on the custom transport instead")
+	}
+	c.Transport = rt
+	return nil
+}
+
+// refuseTransport fails every request with err. It stands in for a
+// transport whose TLS settings could not be applied, so the client never
+// connects without them.
+type refuseTransport struct {
+	err error
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t refuseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return nil, t.err
+}
@@ -0,0 +1,125 @@
+package types
+
+import (
+	"sort"
+	"time"
+)
+
+// Endpoint identifies an image API endpoint.
+type Endpoint string
+
+// Image API endpoints.
+const (
+	EndpointCreate Endpoint = "create"
+	EndpointEdit   Endpoint = "edit"
+	EndpointRemix  Endpoint = "remix"
+)
+
+// String returns the string representation.
+func (e Endpoint) String() string {
+	return string(e)
+}
+
+// Path returns the API path for the endpoint.
+func (e Endpoint) Path() string {
+	return "/v1/image/" + string(e)
+}
+
+// ModelInfo describes a model version in the catalogue.
+type ModelInfo struct {
+	// Version is the model version identifier.
+	Version ModelVersion
+
+	// Endpoint is the endpoint the version belongs to.
+	// Empty for aliases, which are accepted by every endpoint.
+	Endpoint Endpoint
+
+	// Alias is true for server-resolved versions such as "latest".
+	Alias bool
+
+	// Fast is true for fast, lower cost variants.
+	Fast bool
+
+	// Released is the release date. Zero for aliases.
+	Released time.Time
+
+	// Deprecated is true if the version is scheduled for removal. No
+	// catalogued version is deprecated yet; image.Service.Versions also
+	// sets it for versions the API has reported with a Deprecation
+	// response header.
+	Deprecated bool
+}
+
+var catalog = []ModelInfo{
+	{Version: VersionLatest, Alias: true},
+	{Version: VersionLatestFast, Alias: true, Fast: true},
+	{Version: VersionCreate20250915, Endpoint: EndpointCreate, Released: date(2025, 9, 15)},
+	{Version: VersionEdit20250915, Endpoint: EndpointEdit, Released: date(2025, 9, 15)},
+	{Version: VersionEditFast20251030, Endpoint: EndpointEdit, Fast: true, Released: date(2025, 10, 30)},
+	{Version: VersionRemix20250915, Endpoint: EndpointRemix, Released: date(2025, 9, 15)},
+	{Version: VersionRemixFast20251030, Endpoint: EndpointRemix, Fast: true, Released: date(2025, 10, 30)},
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// LookupVersion returns catalogue information for a model version.
+func LookupVersion(v ModelVersion) (ModelInfo, bool) {
+	for _, info := range catalog {
+		if info.Version == v {
+			return info, true
+		}
+	}
+	return ModelInfo{}, false
+}
+
+// Versions returns the versions accepted by an endpoint: aliases first,
+// then concrete versions ordered by release date.
+//
+// Example:
+//
+//	for _, m := range types.Versions(types.EndpointEdit) {
+//		fmt.Println(m.Version, m.Fast)
+//	}
+func Versions(endpoint Endpoint) []ModelInfo {
+	var aliases, concrete []ModelInfo
+	for _, info := range catalog {
+		switch {
+		case info.Alias:
+			aliases = append(aliases, info)
+		case info.Endpoint == endpoint:
+			concrete = append(concrete, info)
+		}
+	}
+	sort.SliceStable(concrete, func(i, j int) bool {
+		return concrete[i].Released.Before(concrete[j].Released)
+	})
+	return append(aliases, concrete...)
+}
+
+// Info returns catalogue information for the version.
+func (v ModelVersion) Info() (ModelInfo, bool) {
+	return LookupVersion(v)
+}
+
+// IsAlias returns true if the server resolves the version, like "latest".
+func (v ModelVersion) IsAlias() bool {
+	info, ok := LookupVersion(v)
+	return ok && info.Alias
+}
+
+// ValidFor reports whether the version may be sent to an endpoint.
+// Empty versions and aliases are valid everywhere. Versions missing
+// from the catalogue are allowed so that newly released models can be
+// used before the SDK is updated.
+func (v ModelVersion) ValidFor(endpoint Endpoint) bool {
+	if v == "" {
+		return true
+	}
+	info, ok := LookupVersion(v)
+	if !ok || info.Alias {
+		return true
+	}
+	return info.Endpoint == endpoint
+}
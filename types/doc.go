@@ -3,6 +3,7 @@
 // This package contains all the common types used across the SDK:
 //   - AspectRatio: Image aspect ratios (16:9, 9:16, etc.)
 //   - ModelVersion: Model versions (latest, fast, specific versions)
+//   - ModelInfo: Model catalogue mapping versions to endpoints
 //   - OutputFormat: Response formats (JSON, PNG, JPEG, WebP)
 //   - Postprocess: Post-processing operations (upscale, remove background)
 //   - Image: Image handling utilities
@@ -4,6 +4,8 @@ import (
 	"encoding/base64"
 	"fmt"
 	"os"
+	"regexp"
+	"strconv"
 )
 
 // Image represents an image for API operations.
@@ -88,3 +90,28 @@ func (img *Image) Size() int {
 func Ref(index int) string {
 	return fmt.Sprintf("<img>%d</img>", index)
 }
+
+var refPattern = regexp.MustCompile(`<img>\s*(-?\d+)\s*</img>`)
+
+// ParseRefs returns the indices of all image reference tags in a prompt,
+// in order of appearance. Duplicates are preserved.
+//
+// Example:
+//
+//	refs := types.ParseRefs("Put <img>1</img> into <img>0</img>")
+//	// refs == []int{1, 0}
+func ParseRefs(prompt string) []int {
+	matches := refPattern.FindAllStringSubmatch(prompt, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+	refs := make([]int, 0, len(matches))
+	for _, m := range matches {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			continue
+		}
+		refs = append(refs, n)
+	}
+	return refs
+}
@@ -1,5 +1,7 @@
 package types
 
+import "errors"
+
 // ProcessType represents postprocessing operation types.
 type ProcessType string
 
@@ -54,9 +56,18 @@ func (p Postprocess) Validate() error {
 	return nil
 }
 
+// ErrInvalidUpscaleFactor is the sentinel for invalid upscale factors.
+var ErrInvalidUpscaleFactor = errors.New("upscale factor must be 2, 3, or 4")
+
 // ErrInvalidUpscale is returned for invalid upscale factors.
+// It matches ErrInvalidUpscaleFactor with errors.Is.
 type ErrInvalidUpscale struct{}
 
 func (e ErrInvalidUpscale) Error() string {
-	return "upscale factor must be 2, 3, or 4"
+	return ErrInvalidUpscaleFactor.Error()
+}
+
+// Unwrap returns ErrInvalidUpscaleFactor.
+func (e ErrInvalidUpscale) Unwrap() error {
+	return ErrInvalidUpscaleFactor
 }
@@ -0,0 +1,88 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PromptBuilder builds remix prompts and assigns image reference
+// indices automatically as images are attached.
+//
+// Example:
+//
+//	b := types.NewPromptBuilder().
+//		Text("Apply the style of ").Image(style).
+//		Text(" to the scene in ").Image(scene)
+//
+//	params := &image.RemixParams{
+//		Prompt:          b.String(),
+//		ReferenceImages: b.Images(),
+//	}
+type PromptBuilder struct {
+	sb     strings.Builder
+	images []*Image
+	index  map[*Image]int
+}
+
+// NewPromptBuilder creates an empty prompt builder.
+func NewPromptBuilder() *PromptBuilder {
+	return &PromptBuilder{index: make(map[*Image]int)}
+}
+
+// Text appends literal text to the prompt.
+func (b *PromptBuilder) Text(s string) *PromptBuilder {
+	b.sb.WriteString(s)
+	return b
+}
+
+// Textf appends formatted text to the prompt.
+func (b *PromptBuilder) Textf(format string, args ...any) *PromptBuilder {
+	fmt.Fprintf(&b.sb, format, args...)
+	return b
+}
+
+// Image appends a reference tag for img to the prompt.
+// Attaching the same image twice reuses its index.
+func (b *PromptBuilder) Image(img *Image) *PromptBuilder {
+	b.sb.WriteString(b.Ref(img))
+	return b
+}
+
+// Ref attaches img and returns its reference tag without appending it
+// to the prompt. Useful with fmt.Sprintf style prompts.
+//
+// Example:
+//
+//	b := types.NewPromptBuilder()
+//	b.Textf("Blend %s with %s", b.Ref(a), b.Ref(c))
+func (b *PromptBuilder) Ref(img *Image) string {
+	if b.index == nil {
+		b.index = make(map[*Image]int)
+	}
+	idx, ok := b.index[img]
+	if !ok {
+		idx = len(b.images)
+		b.images = append(b.images, img)
+		b.index[img] = idx
+	}
+	return Ref(idx)
+}
+
+// String returns the prompt text.
+func (b *PromptBuilder) String() string {
+	return b.sb.String()
+}
+
+// Images returns the attached images as base64 strings, ordered by index.
+func (b *PromptBuilder) Images() []string {
+	out := make([]string, len(b.images))
+	for i, img := range b.images {
+		out[i] = img.Base64()
+	}
+	return out
+}
+
+// Len returns the number of attached images.
+func (b *PromptBuilder) Len() int {
+	return len(b.images)
+}
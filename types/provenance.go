@@ -0,0 +1,268 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Provenance errors.
+var (
+	ErrNoProvenance      = errors.New("types: no provenance metadata found")
+	ErrUnsupportedFormat = errors.New("types: unsupported image format")
+)
+
+// ProvenanceNamespace is the XMP namespace used for provenance fields.
+const ProvenanceNamespace = "https://reve.com/ns/provenance/1.0/"
+
+// Provenance describes how an image was generated. It is embedded in
+// image files as XMP metadata and can be written to a JSON sidecar.
+type Provenance struct {
+	Prompt      string    `json:"prompt,omitempty"`
+	Endpoint    Endpoint  `json:"endpoint,omitempty"`
+	Version     string    `json:"version,omitempty"`
+	RequestID   string    `json:"request_id,omitempty"`
+	Breadcrumb  string    `json:"breadcrumb,omitempty"`
+	CreditsUsed int       `json:"credits_used,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SaveOption configures Result.SaveTo and RawResult.SaveTo.
+type SaveOption func(*saveOptions)
+
+type saveOptions struct {
+	provenance *Provenance
+	sidecar    bool
+}
+
+// WithProvenance embeds p in the saved file. Version, RequestID,
+// CreditsUsed and CreatedAt are filled in from the result when empty.
+//
+// Example:
+//
+//	err := result.SaveTo("lake.png", types.WithProvenance(types.Provenance{
+//		Prompt:   params.Prompt,
+//		Endpoint: types.EndpointCreate,
+//	}))
+func WithProvenance(p Provenance) SaveOption {
+	return func(o *saveOptions) {
+		o.provenance = &p
+	}
+}
+
+// WithSidecar also writes the provenance to path + ".json".
+// It has no effect without WithProvenance.
+func WithSidecar() SaveOption {
+	return func(o *saveOptions) {
+		o.sidecar = true
+	}
+}
+
+// save writes data to path, applying the save options.
+func save(path string, data []byte, version, requestID string, credits int, opts []SaveOption) error {
+	var o saveOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.provenance == nil {
+		return os.WriteFile(path, data, 0644)
+	}
+
+	p := *o.provenance
+	if p.Version == "" {
+		p.Version = version
+	}
+	if p.RequestID == "" {
+		p.RequestID = requestID
+	}
+	if p.CreditsUsed == 0 {
+		p.CreditsUsed = credits
+	}
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = time.Now().UTC()
+	}
+
+	out, err := EmbedProvenance(data, p)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		return err
+	}
+	if o.sidecar {
+		return WriteProvenanceSidecar(path, p)
+	}
+	return nil
+}
+
+// EmbedProvenance returns a copy of a PNG, JPEG or WebP image with p
+// stored as XMP metadata. Existing XMP metadata is replaced.
+func EmbedProvenance(data []byte, p Provenance) ([]byte, error) {
+	packet := p.xmp()
+	switch sniff(data) {
+	case FormatPNG:
+		return embedPNG(data, packet)
+	case FormatJPEG:
+		return embedJPEG(data, packet)
+	case FormatWebP:
+		return embedWebP(data, packet)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// ReadProvenance extracts provenance embedded by EmbedProvenance.
+func ReadProvenance(data []byte) (*Provenance, error) {
+	var (
+		packet []byte
+		err    error
+	)
+	switch sniff(data) {
+	case FormatPNG:
+		packet, err = extractPNG(data)
+	case FormatJPEG:
+		packet, err = extractJPEG(data)
+	case FormatWebP:
+		packet, err = extractWebP(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if packet == nil {
+		return nil, ErrNoProvenance
+	}
+	return parseXMP(packet)
+}
+
+// ReadProvenanceFile reads provenance from an image file, falling back
+// to its JSON sidecar.
+func ReadProvenanceFile(path string) (*Provenance, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := ReadProvenance(data)
+	if err == nil || !errors.Is(err, ErrNoProvenance) && !errors.Is(err, ErrUnsupportedFormat) {
+		return p, err
+	}
+
+	sidecar, serr := os.ReadFile(path + ".json")
+	if serr != nil {
+		return nil, err
+	}
+	p = new(Provenance)
+	if err := json.Unmarshal(sidecar, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// WriteProvenanceSidecar writes p as JSON to path + ".json".
+func WriteProvenanceSidecar(path string, p Provenance) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path+".json", append(data, '\n'), 0644)
+}
+
+// sniff detects the image format from its magic bytes.
+func sniff(data []byte) OutputFormat {
+	switch {
+	case bytes.HasPrefix(data, pngSignature):
+		return FormatPNG
+	case len(data) > 3 && data[0] == 0xFF && data[1] == 0xD8 && data[2] == 0xFF:
+		return FormatJPEG
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return FormatWebP
+	}
+	return ""
+}
+
+// xmp renders p as an XMP packet.
+func (p Provenance) xmp() []byte {
+	var b strings.Builder
+	attr := func(name, value string) {
+		if value == "" {
+			return
+		}
+		b.WriteString("\n   ")
+		b.WriteString(name)
+		b.WriteString(`="`)
+		_ = xml.EscapeText(&b, []byte(value))
+		b.WriteString(`"`)
+	}
+
+	b.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString(`<x:xmpmeta xmlns:x="adobe:ns:meta/">` + "\n")
+	b.WriteString(` <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` + "\n")
+	b.WriteString(`  <rdf:Description rdf:about=""`)
+	b.WriteString("\n   xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\"")
+	b.WriteString("\n   xmlns:reve=\"" + ProvenanceNamespace + "\"")
+	attr("xmp:CreatorTool", "reve-go")
+	attr("xmp:CreateDate", p.CreatedAt.UTC().Format(time.RFC3339))
+	attr("reve:Prompt", p.Prompt)
+	attr("reve:Endpoint", string(p.Endpoint))
+	attr("reve:Version", p.Version)
+	attr("reve:RequestID", p.RequestID)
+	attr("reve:Breadcrumb", p.Breadcrumb)
+	attr("reve:CreditsUsed", strconv.Itoa(p.CreditsUsed))
+	b.WriteString("/>\n </rdf:RDF>\n</x:xmpmeta>\n<?xpacket end=\"w\"?>")
+	return []byte(b.String())
+}
+
+// parseXMP reads provenance fields from an XMP packet.
+func parseXMP(packet []byte) (*Provenance, error) {
+	dec := xml.NewDecoder(bytes.NewReader(packet))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, ErrNoProvenance
+		}
+		if err != nil {
+			return nil, fmt.Errorf("types: invalid XMP: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "Description" {
+			continue
+		}
+
+		var p Provenance
+		found := false
+		for _, a := range start.Attr {
+			if a.Name.Space == "http://ns.adobe.com/xap/1.0/" && a.Name.Local == "CreateDate" {
+				p.CreatedAt, _ = time.Parse(time.RFC3339, a.Value)
+				continue
+			}
+			if a.Name.Space != ProvenanceNamespace {
+				continue
+			}
+			found = true
+			switch a.Name.Local {
+			case "Prompt":
+				p.Prompt = a.Value
+			case "Endpoint":
+				p.Endpoint = Endpoint(a.Value)
+			case "Version":
+				p.Version = a.Value
+			case "RequestID":
+				p.RequestID = a.Value
+			case "Breadcrumb":
+				p.Breadcrumb = a.Value
+			case "CreditsUsed":
+				p.CreditsUsed, _ = strconv.Atoi(a.Value)
+			}
+		}
+		if found {
+			return &p, nil
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+var errCorrupt = errors.New("types: corrupt image data")
+
+// PNG
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+// pngXMPKeyword is the iTXt keyword reserved for XMP packets.
+const pngXMPKeyword = "XML:com.adobe.xmp"
+
+// pngChunks calls fn for each chunk with its type, data and the raw
+// chunk bytes including length and CRC.
+func pngChunks(data []byte, fn func(typ string, body, raw []byte)) error {
+	rest := data[len(pngSignature):]
+	for len(rest) > 0 {
+		if len(rest) < 12 {
+			return errCorrupt
+		}
+		n := int(binary.BigEndian.Uint32(rest))
+		if n < 0 || len(rest) < 12+n {
+			return errCorrupt
+		}
+		fn(string(rest[4:8]), rest[8:8+n], rest[:12+n])
+		rest = rest[12+n:]
+	}
+	return nil
+}
+
+func isPNGXMP(typ string, body []byte) bool {
+	return typ == "iTXt" && bytes.HasPrefix(body, []byte(pngXMPKeyword+"\x00"))
+}
+
+func embedPNG(data, packet []byte) ([]byte, error) {
+	// iTXt: keyword, NUL, compression flag and method, empty language
+	// tag and translated keyword, then the UTF-8 text.
+	body := append([]byte(pngXMPKeyword+"\x00\x00\x00\x00\x00"), packet...)
+	chunk := make([]byte, 8, 12+len(body))
+	binary.BigEndian.PutUint32(chunk, uint32(len(body)))
+	copy(chunk[4:], "iTXt")
+	chunk = append(chunk, body...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+
+	out := append(make([]byte, 0, len(data)+len(chunk)), pngSignature...)
+	err := pngChunks(data, func(typ string, body, raw []byte) {
+		if isPNGXMP(typ, body) {
+			return
+		}
+		out = append(out, raw...)
+		if typ == "IHDR" {
+			out = append(out, chunk...)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func extractPNG(data []byte) ([]byte, error) {
+	var packet []byte
+	err := pngChunks(data, func(typ string, body, _ []byte) {
+		if packet == nil && isPNGXMP(typ, body) {
+			packet = body[len(pngXMPKeyword)+5:]
+		}
+	})
+	return packet, err
+}
+
+// JPEG
+
+// jpegXMPHeader identifies an APP1 segment holding XMP.
+const jpegXMPHeader = "http://ns.adobe.com/xap/1.0/\x00"
+
+const (
+	jpegSOS  = 0xDA
+	jpegAPP0 = 0xE0
+	jpegAPP1 = 0xE1
+)
+
+type jpegSegment struct {
+	marker byte
+	body   []byte
+	raw    []byte
+}
+
+// jpegSegments splits the header segments before the first SOS and
+// returns them with the remaining bytes.
+func jpegSegments(data []byte) ([]jpegSegment, []byte, error) {
+	var segs []jpegSegment
+	rest := data[2:]
+	for {
+		// Skip fill bytes.
+		for len(rest) > 1 && rest[0] == 0xFF && rest[1] == 0xFF {
+			rest = rest[1:]
+		}
+		if len(rest) < 2 || rest[0] != 0xFF {
+			return nil, nil, errCorrupt
+		}
+		marker := rest[1]
+		if marker == jpegSOS {
+			return segs, rest, nil
+		}
+		if marker >= 0xD0 && marker <= 0xD7 || marker == 0x01 {
+			segs = append(segs, jpegSegment{marker: marker, raw: rest[:2]})
+			rest = rest[2:]
+			continue
+		}
+		if len(rest) < 4 {
+			return nil, nil, errCorrupt
+		}
+		n := int(binary.BigEndian.Uint16(rest[2:]))
+		if n < 2 || len(rest) < 2+n {
+			return nil, nil, errCorrupt
+		}
+		segs = append(segs, jpegSegment{marker: marker, body: rest[4 : 2+n], raw: rest[:2+n]})
+		rest = rest[2+n:]
+	}
+}
+
+func isJPEGXMP(s jpegSegment) bool {
+	return s.marker == jpegAPP1 && bytes.HasPrefix(s.body, []byte(jpegXMPHeader))
+}
+
+func embedJPEG(data, packet []byte) ([]byte, error) {
+	n := 2 + len(jpegXMPHeader) + len(packet)
+	if n > 0xFFFF {
+		return nil, errors.New("types: provenance too large for a JPEG segment")
+	}
+	segs, rest, err := jpegSegments(data)
+	if err != nil {
+		return nil, err
+	}
+
+	xmp := []byte{0xFF, jpegAPP1, byte(n >> 8), byte(n)}
+	xmp = append(xmp, jpegXMPHeader...)
+	xmp = append(xmp, packet...)
+
+	out := append(make([]byte, 0, len(data)+len(xmp)), 0xFF, 0xD8)
+	inserted := false
+	for _, s := range segs {
+		if isJPEGXMP(s) {
+			continue
+		}
+		// Keep JFIF and Exif first, as readers expect.
+		if !inserted && s.marker != jpegAPP0 && s.marker != jpegAPP1 {
+			out = append(out, xmp...)
+			inserted = true
+		}
+		out = append(out, s.raw...)
+	}
+	if !inserted {
+		out = append(out, xmp...)
+	}
+	return append(out, rest...), nil
+}
+
+func extractJPEG(data []byte) ([]byte, error) {
+	segs, _, err := jpegSegments(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range segs {
+		if isJPEGXMP(s) {
+			return s.body[len(jpegXMPHeader):], nil
+		}
+	}
+	return nil, nil
+}
+
+// WebP
+
+const (
+	webpFlagXMP   = 0x04
+	webpFlagAlpha = 0x10
+)
+
+type webpChunk struct {
+	fourcc string
+	body   []byte
+}
+
+func webpChunks(data []byte) ([]webpChunk, error) {
+	var chunks []webpChunk
+	rest := data[12:]
+	for len(rest) > 0 {
+		if len(rest) < 8 {
+			return nil, errCorrupt
+		}
+		n := int(binary.LittleEndian.Uint32(rest[4:]))
+		if n < 0 || len(rest) < 8+n {
+			return nil, errCorrupt
+		}
+		chunks = append(chunks, webpChunk{fourcc: string(rest[:4]), body: rest[8 : 8+n]})
+		rest = rest[8+n:]
+		if n%2 == 1 && len(rest) > 0 {
+			rest = rest[1:]
+		}
+	}
+	return chunks, nil
+}
+
+// webpCanvas returns the dimensions and alpha flag of a simple-format
+// VP8 or VP8L bitstream.
+func webpCanvas(c webpChunk) (width, height int, alpha bool, err error) {
+	switch c.fourcc {
+	case "VP8 ":
+		if len(c.body) < 10 || !bytes.Equal(c.body[3:6], []byte{0x9d, 0x01, 0x2a}) {
+			return 0, 0, false, errCorrupt
+		}
+		width = int(binary.LittleEndian.Uint16(c.body[6:]) & 0x3fff)
+		height = int(binary.LittleEndian.Uint16(c.body[8:]) & 0x3fff)
+		return width, height, false, nil
+	case "VP8L":
+		if len(c.body) < 5 || c.body[0] != 0x2f {
+			return 0, 0, false, errCorrupt
+		}
+		bits := binary.LittleEndian.Uint32(c.body[1:])
+		return int(bits&0x3fff) + 1, int(bits>>14&0x3fff) + 1, bits>>28&1 == 1, nil
+	}
+	return 0, 0, false, errCorrupt
+}
+
+func embedWebP(data, packet []byte) ([]byte, error) {
+	chunks, err := webpChunks(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) == 0 {
+		return nil, errCorrupt
+	}
+
+	if chunks[0].fourcc == "VP8X" {
+		if len(chunks[0].body) < 10 {
+			return nil, errCorrupt
+		}
+		vp8x := bytes.Clone(chunks[0].body)
+		vp8x[0] |= webpFlagXMP
+		chunks[0].body = vp8x
+	} else {
+		width, height, alpha, err := webpCanvas(chunks[0])
+		if err != nil {
+			return nil, err
+		}
+		vp8x := make([]byte, 10)
+		vp8x[0] = webpFlagXMP
+		if alpha {
+			vp8x[0] |= webpFlagAlpha
+		}
+		putUint24(vp8x[4:], width-1)
+		putUint24(vp8x[7:], height-1)
+		chunks = append([]webpChunk{{fourcc: "VP8X", body: vp8x}}, chunks...)
+	}
+
+	out := append(make([]byte, 0, len(data)+len(packet)+32), "RIFF\x00\x00\x00\x00WEBP"...)
+	for _, c := range chunks {
+		if c.fourcc != "XMP " {
+			out = appendWebPChunk(out, c.fourcc, c.body)
+		}
+	}
+	out = appendWebPChunk(out, "XMP ", packet)
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
+
+func appendWebPChunk(out []byte, fourcc string, body []byte) []byte {
+	out = append(out, fourcc...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(body)))
+	out = append(out, body...)
+	if len(body)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+func putUint24(b []byte, v int) {
+	b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+}
+
+func extractWebP(data []byte) ([]byte, error) {
+	chunks, err := webpChunks(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range chunks {
+		if c.fourcc == "XMP " {
+			return c.body, nil
+		}
+	}
+	return nil, nil
+}
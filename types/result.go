@@ -2,7 +2,6 @@ package types
 
 import (
 	"encoding/base64"
-	"os"
 )
 
 // Result represents an image generation result.
@@ -24,6 +23,17 @@ type Result struct {
 
 	// CreditsRemaining is the remaining credits.
 	CreditsRemaining int `json:"credits_remaining"`
+
+	// KeyLabel identifies the pooled API key that served the request.
+	// It is empty unless the client uses a key pool.
+	KeyLabel string `json:"-"`
+
+	// BaseURL is the API base URL that served the request.
+	BaseURL string `json:"-"`
+
+	// Deprecated is true if the API reported the model version as
+	// deprecated.
+	Deprecated bool `json:"-"`
 }
 
 // Bytes returns the raw image bytes.
@@ -32,17 +42,24 @@ func (r *Result) Bytes() ([]byte, error) {
 }
 
 // SaveTo saves the image to a file.
+// Use WithProvenance to embed how the image was generated.
 //
 // Example:
 //
 //	result, _ := client.Images.Create(ctx, params)
 //	err := result.SaveTo("output.png")
-func (r *Result) SaveTo(path string) error {
+//
+//	// With provenance and a JSON sidecar
+//	err = result.SaveTo("output.png",
+//		types.WithProvenance(types.Provenance{Prompt: params.Prompt}),
+//		types.WithSidecar(),
+//	)
+func (r *Result) SaveTo(path string, opts ...SaveOption) error {
 	data, err := r.Bytes()
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0644)
+	return save(path, data, r.Version, r.RequestID, r.CreditsUsed, opts)
 }
 
 // RawResult represents a raw binary response.
@@ -67,11 +84,59 @@ type RawResult struct {
 
 	// CreditsRemaining is the remaining credits.
 	CreditsRemaining int
+
+	// KeyLabel identifies the pooled API key that served the request.
+	// It is empty unless the client uses a key pool.
+	KeyLabel string
+
+	// BaseURL is the API base URL that served the request.
+	BaseURL string
+
+	// Deprecated is true if the API reported the model version as
+	// deprecated.
+	Deprecated bool
+}
+
+// StreamResult describes an image written to an io.Writer by the
+// CreateTo, EditTo and RemixTo methods.
+type StreamResult struct {
+	// ContentType is the MIME type of the image.
+	ContentType string
+
+	// Version is the model version used.
+	Version string
+
+	// ContentViolation indicates if content policy was violated.
+	ContentViolation bool
+
+	// RequestID is the unique request identifier.
+	RequestID string
+
+	// CreditsUsed is the number of credits consumed.
+	CreditsUsed int
+
+	// CreditsRemaining is the remaining credits.
+	CreditsRemaining int
+
+	// KeyLabel identifies the pooled API key that served the request.
+	// It is empty unless the client uses a key pool.
+	KeyLabel string
+
+	// BaseURL is the API base URL that served the request.
+	BaseURL string
+
+	// Deprecated is true if the API reported the model version as
+	// deprecated.
+	Deprecated bool
+
+	// Size is the number of bytes written.
+	Size int64
 }
 
 // SaveTo saves the raw image to a file.
-func (r *RawResult) SaveTo(path string) error {
-	return os.WriteFile(path, r.Data, 0644)
+// Use WithProvenance to embed how the image was generated.
+func (r *RawResult) SaveTo(path string, opts ...SaveOption) error {
+	return save(path, r.Data, r.Version, r.RequestID, r.CreditsUsed, opts)
 }
 
 // Size returns the size in bytes.
@@ -0,0 +1,93 @@
+package types
+
+import (
+	"errors"
+	"strings"
+)
+
+// Validation error codes.
+const (
+	CodeRequired         = "required"
+	CodeTooLong          = "too_long"
+	CodeTooMany          = "too_many"
+	CodeInvalidValue     = "invalid_value"
+	CodeOutOfRange       = "out_of_range"
+	CodeIndexOutOfBounds = "index_out_of_bounds"
+	CodeUnsupported      = "unsupported"
+)
+
+// FieldError describes a problem with a single request field.
+type FieldError struct {
+	// Path is the JSON path of the field, e.g. "postprocessing[1].upscale_factor".
+	Path string `json:"path"`
+
+	// Code is a machine readable error code, e.g. "out_of_range".
+	Code string `json:"code"`
+
+	// Message is a human readable description.
+	Message string `json:"message"`
+
+	// Err is the underlying error, usually a validation sentinel.
+	Err error `json:"-"`
+}
+
+// Error implements the error interface.
+func (e *FieldError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// Unwrap returns the underlying error.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError collects every problem found while validating request
+// parameters. It matches the underlying sentinels with errors.Is.
+//
+// Example:
+//
+//	err := params.Validate()
+//	var verr *types.ValidationError
+//	if errors.As(err, &verr) {
+//		w.WriteHeader(http.StatusUnprocessableEntity)
+//		json.NewEncoder(w).Encode(verr)
+//	}
+type ValidationError struct {
+	Errors []*FieldError `json:"errors"`
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return "invalid parameters: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the field errors so that errors.Is and errors.As
+// match against each of them.
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, fe := range e.Errors {
+		errs[i] = fe
+	}
+	return errs
+}
+
+// Field returns the first error for a JSON path, or nil.
+func (e *ValidationError) Field(path string) *FieldError {
+	for _, fe := range e.Errors {
+		if fe.Path == path {
+			return fe
+		}
+	}
+	return nil
+}
+
+// AsValidationError returns err as a *ValidationError if it is one.
+func AsValidationError(err error) (*ValidationError, bool) {
+	var verr *ValidationError
+	ok := errors.As(err, &verr)
+	return verr, ok
+}